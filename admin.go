@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/utils"
+)
+
+// runAdmin implements the "admin create|grant" subcommands used to
+// bootstrap staff accounts.
+//
+//	create -email E -name N  create an admin account; the password is read
+//	                         from the ADMIN_PASSWORD environment variable
+//	grant EMAIL ROLE         give an existing account one of the roles
+func runAdmin(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: admin create|grant")
+	}
+
+	repos := repositories.New(db)
+	ctx := context.Background()
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("admin create", flag.ContinueOnError)
+		email := fs.String("email", "", "email address of the admin")
+		name := fs.String("name", "Administrator", "full name of the admin")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *email == "" {
+			return errors.New("usage: admin create -email EMAIL [-name NAME]")
+		}
+		password := os.Getenv("ADMIN_PASSWORD")
+		if len(password) < 8 || len(password) > 72 {
+			return errors.New("ADMIN_PASSWORD must be set to a password of 8 to 72 bytes")
+		}
+
+		hashedPassword, err := utils.HashPassword(password)
+		if err != nil {
+			return err
+		}
+		// The operator vouches for the address, so it starts verified
+		now := time.Now().UTC()
+		id, err := repos.Users.Create(ctx, models.RegisterUserModel{
+			FullName:        strings.TrimSpace(*name),
+			Email:           strings.TrimSpace(*email),
+			Password:        hashedPassword,
+			Role:            models.RoleAdmin,
+			EmailVerifiedAt: &now,
+		})
+		if errors.Is(err, repositories.ErrDuplicateEmail) {
+			return fmt.Errorf("%s is already registered, use admin grant %s %s instead", *email, *email, models.RoleAdmin)
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Created admin %s with ID %d", *email, id)
+	case "grant":
+		if len(args) != 3 {
+			return errors.New("usage: admin grant EMAIL ROLE")
+		}
+		email, role := args[1], args[2]
+		if !models.ValidRole(role) {
+			return fmt.Errorf("unknown role %q, expected one of %s", role, strings.Join(models.Roles, ", "))
+		}
+
+		user, err := repos.Users.FindByEmail(ctx, email)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("no account is registered with %s", email)
+		}
+		if err != nil {
+			return err
+		}
+		if err := repos.Users.UpdateRole(ctx, user.ID, role); err != nil {
+			return err
+		}
+		// Access tokens carry the old role until they expire; end every
+		// session so it cannot be used any longer.
+		now := utils.RevocationCutoff()
+		if err := repos.Tokens.RevokeUserRefreshTokens(ctx, user.ID, now); err != nil {
+			return err
+		}
+		if err := repos.Tokens.RevokeUserAccessTokens(ctx, user.ID, now, now.Add(utils.AccessTokenTTL)); err != nil {
+			return err
+		}
+		log.Printf("Changed the role of %s from %s to %s", email, user.Role, role)
+	default:
+		return fmt.Errorf("unknown admin command %q, expected create or grant", args[0])
+	}
+	return nil
+}
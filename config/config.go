@@ -0,0 +1,555 @@
+// Package config loads the typed application configuration.
+//
+// Values are resolved in the following order, each step overriding the
+// previous one:
+//
+//  1. built-in defaults
+//  2. the YAML file for the selected environment (configs/<env>.yaml)
+//  3. environment variables
+//  4. command-line flags
+//
+// The result is validated before it is returned so the server refuses to
+// start with a configuration it cannot run safely with.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environments lists the environment names a configuration can target.
+var Environments = []string{"dev", "staging", "prod"}
+
+// Config is the complete application configuration.
+type Config struct {
+	Env      string         `yaml:"env"`
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	CORS     CORSConfig     `yaml:"cors"`
+	Storage  StorageConfig  `yaml:"storage"`
+	Accounts AccountsConfig `yaml:"accounts"`
+	Comments CommentsConfig `yaml:"comments"`
+	Mail     MailConfig     `yaml:"mail"`
+}
+
+// ServerConfig configures the HTTP listener.
+//
+// The timeouts map onto the fields of http.Server. On SIGINT or SIGTERM
+// the readiness probe fails immediately and the server keeps serving for
+// DrainDelay so load balancers can take it out of rotation; in-flight
+// requests then get the rest of ShutdownTimeout to finish. Both together
+// should stay below the orchestrator's grace period.
+type ServerConfig struct {
+	Addr              string        `yaml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes"`
+	DrainDelay        time.Duration `yaml:"drain_delay"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+}
+
+// DatabaseConfig configures the SQL connection pool.
+//
+// Driver is "mysql" or "sqlite". For SQLite the DSN is the path of the
+// database file, e.g. "blog.db".
+type DatabaseConfig struct {
+	Driver string `yaml:"driver"`
+	DSN    string `yaml:"dsn"`
+}
+
+// DatabaseDrivers lists the supported database drivers.
+var DatabaseDrivers = []string{"mysql", "sqlite"}
+
+// AuthConfig configures token signing and lifetimes.
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+// AccountsConfig configures the lifecycle of user accounts.
+//
+// A deleted account is kept, unable to log in without cancelling the
+// deletion, for DeletionGracePeriod before its data is purged. Zero
+// purges it immediately.
+//
+// Password reset mails link to PasswordResetURL with the reset token in
+// the token query parameter; the token is valid for PasswordResetTTL.
+//
+// New accounts are sent a link to EmailVerificationURL, valid for
+// EmailVerificationTTL, and can ask for it again once every
+// VerificationResendInterval. AllowUnverifiedLogin and
+// AllowUnverifiedPosting decide what an account can do before its email
+// is verified.
+type AccountsConfig struct {
+	DeletionGracePeriod        time.Duration `yaml:"deletion_grace_period"`
+	PasswordResetURL           string        `yaml:"password_reset_url"`
+	PasswordResetTTL           time.Duration `yaml:"password_reset_ttl"`
+	EmailVerificationURL       string        `yaml:"email_verification_url"`
+	EmailVerificationTTL       time.Duration `yaml:"email_verification_ttl"`
+	VerificationResendInterval time.Duration `yaml:"verification_resend_interval"`
+	AllowUnverifiedLogin       bool          `yaml:"allow_unverified_login"`
+	AllowUnverifiedPosting     bool          `yaml:"allow_unverified_posting"`
+}
+
+// CommentsConfig configures comments on stories. Their authors can edit
+// or delete them for EditWindow after posting them; zero never lets them.
+type CommentsConfig struct {
+	EditWindow time.Duration `yaml:"edit_window"`
+}
+
+// MailConfig selects how outgoing mail is delivered.
+//
+// Driver is "smtp", "file" or "log". The file and log drivers never send
+// anything and are meant for local development.
+type MailConfig struct {
+	Driver string         `yaml:"driver"`
+	From   string         `yaml:"from"`
+	SMTP   SMTPMailConfig `yaml:"smtp"`
+	File   FileMailConfig `yaml:"file"`
+}
+
+// SMTPMailConfig configures the SMTP mail driver.
+type SMTPMailConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// FileMailConfig configures the file mail driver.
+type FileMailConfig struct {
+	Dir string `yaml:"dir"`
+}
+
+// MailDrivers lists the supported mail drivers.
+var MailDrivers = []string{"smtp", "file", "log"}
+
+// CORSConfig configures cross-origin access for the frontend.
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	Debug          bool     `yaml:"debug"`
+}
+
+// StorageConfig selects where uploaded files are kept.
+//
+// Driver is "local", "s3" or "memory". PublicURL is the base URL that
+// download links are built from; the default serves files through the
+// API's own /uploads/ route whatever the driver. MaxUploadBytes caps the
+// size of every uploaded file.
+type StorageConfig struct {
+	Driver         string             `yaml:"driver"`
+	PublicURL      string             `yaml:"public_url"`
+	MaxUploadBytes int64              `yaml:"max_upload_bytes"`
+	Local          LocalStorageConfig `yaml:"local"`
+	S3             S3StorageConfig    `yaml:"s3"`
+}
+
+// LocalStorageConfig configures the local filesystem storage driver.
+type LocalStorageConfig struct {
+	Dir string `yaml:"dir"`
+}
+
+// S3StorageConfig configures the S3-compatible storage driver.
+type S3StorageConfig struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	PathStyle       bool   `yaml:"path_style"`
+}
+
+// StorageDrivers lists the supported storage drivers.
+var StorageDrivers = []string{"local", "s3", "memory"}
+
+// Default returns the configuration used before any file, environment
+// variable or flag is applied.
+func Default() *Config {
+	return &Config{
+		Env: "dev",
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       60 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   25 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver: "mysql",
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:3000"},
+		},
+		Accounts: AccountsConfig{
+			DeletionGracePeriod:        30 * 24 * time.Hour,
+			PasswordResetURL:           "http://localhost:3000/reset-password",
+			PasswordResetTTL:           time.Hour,
+			EmailVerificationURL:       "http://localhost:8080/api/verify-email",
+			EmailVerificationTTL:       48 * time.Hour,
+			VerificationResendInterval: 2 * time.Minute,
+			AllowUnverifiedLogin:       true,
+		},
+		Comments: CommentsConfig{
+			EditWindow: 15 * time.Minute,
+		},
+		Mail: MailConfig{
+			Driver: "log",
+			From:   "no-reply@localhost",
+			SMTP:   SMTPMailConfig{Port: 587},
+			File:   FileMailConfig{Dir: "mailbox"},
+		},
+		Storage: StorageConfig{
+			Driver:         "local",
+			PublicURL:      "/uploads/",
+			MaxUploadBytes: 5 << 20,
+			Local:          LocalStorageConfig{Dir: "uploads"},
+			S3:             S3StorageConfig{Region: "us-east-1", PathStyle: true},
+		},
+	}
+}
+
+// Load builds the configuration from the command-line arguments (without
+// the program name), the environment and the configuration file they
+// point to.
+//
+// It returns the validated configuration together with the positional
+// arguments left over after flag parsing.
+func Load(args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("APP_CONFIG"), "path to the YAML configuration file (default configs/<env>.yaml)")
+	env := fs.String("env", os.Getenv("APP_ENV"), "environment to load: dev, staging or prod")
+	addr := fs.String("addr", "", "address the HTTP server listens on")
+	driver := fs.String("db-driver", "", "database driver: mysql or sqlite")
+	dsn := fs.String("db-dsn", "", "database data source name")
+	corsOrigins := fs.String("cors-origins", "", "comma separated list of allowed CORS origins")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := Default()
+	if *env != "" {
+		cfg.Env = *env
+	}
+
+	path := *configFile
+	if path == "" {
+		path = filepath.Join("configs", cfg.Env+".yaml")
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			path = ""
+		}
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, nil, err
+		}
+		// The selected environment wins over whatever the file claims.
+		if *env != "" {
+			cfg.Env = *env
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, nil, err
+	}
+
+	// Flags have the last word, but only when they were actually given.
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "db-driver":
+			cfg.Database.Driver = *driver
+		case "db-dsn":
+			cfg.Database.DSN = *dsn
+		case "cors-origins":
+			cfg.CORS.AllowedOrigins = splitList(*corsOrigins)
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// loadFile overlays the YAML file at path onto the configuration.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: reading %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overlays the supported environment variables onto the
+// configuration.
+func (c *Config) applyEnv() error {
+	setString := func(name string, target *string) {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = value
+		}
+	}
+	setDuration := func(name string, target *time.Duration) error {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", name, err)
+		}
+		*target = d
+		return nil
+	}
+	setBool := func(name string, target *bool) error {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", name, err)
+		}
+		*target = b
+		return nil
+	}
+
+	setString("HTTP_ADDR", &c.Server.Addr)
+	setString("DB_DRIVER", &c.Database.Driver)
+	setString("DB_DSN", &c.Database.DSN)
+	setString("JWT_SECRET", &c.Auth.JWTSecret)
+	setString("PASSWORD_RESET_URL", &c.Accounts.PasswordResetURL)
+	setString("EMAIL_VERIFICATION_URL", &c.Accounts.EmailVerificationURL)
+	setString("MAIL_DRIVER", &c.Mail.Driver)
+	setString("MAIL_FROM", &c.Mail.From)
+	setString("MAIL_FILE_DIR", &c.Mail.File.Dir)
+	setString("SMTP_HOST", &c.Mail.SMTP.Host)
+	setString("SMTP_USERNAME", &c.Mail.SMTP.Username)
+	setString("SMTP_PASSWORD", &c.Mail.SMTP.Password)
+	if value, ok := os.LookupEnv("SMTP_PORT"); ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("config: SMTP_PORT: %w", err)
+		}
+		c.Mail.SMTP.Port = port
+	}
+	setString("STORAGE_DRIVER", &c.Storage.Driver)
+	setString("STORAGE_PUBLIC_URL", &c.Storage.PublicURL)
+	setString("STORAGE_LOCAL_DIR", &c.Storage.Local.Dir)
+	setString("S3_ENDPOINT", &c.Storage.S3.Endpoint)
+	setString("S3_REGION", &c.Storage.S3.Region)
+	setString("S3_BUCKET", &c.Storage.S3.Bucket)
+	setString("S3_ACCESS_KEY_ID", &c.Storage.S3.AccessKeyID)
+	setString("S3_SECRET_ACCESS_KEY", &c.Storage.S3.SecretAccessKey)
+	if value, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok {
+		c.CORS.AllowedOrigins = splitList(value)
+	}
+
+	for name, target := range map[string]*time.Duration{
+		"HTTP_READ_HEADER_TIMEOUT": &c.Server.ReadHeaderTimeout,
+		"HTTP_READ_TIMEOUT":        &c.Server.ReadTimeout,
+		"HTTP_WRITE_TIMEOUT":       &c.Server.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        &c.Server.IdleTimeout,
+		"HTTP_DRAIN_DELAY":         &c.Server.DrainDelay,
+		"HTTP_SHUTDOWN_TIMEOUT":    &c.Server.ShutdownTimeout,
+	} {
+		if err := setDuration(name, target); err != nil {
+			return err
+		}
+	}
+	if err := setDuration("ACCESS_TOKEN_TTL", &c.Auth.AccessTokenTTL); err != nil {
+		return err
+	}
+	if err := setDuration("REFRESH_TOKEN_TTL", &c.Auth.RefreshTokenTTL); err != nil {
+		return err
+	}
+	if err := setDuration("ACCOUNT_DELETION_GRACE_PERIOD", &c.Accounts.DeletionGracePeriod); err != nil {
+		return err
+	}
+	if err := setDuration("PASSWORD_RESET_TTL", &c.Accounts.PasswordResetTTL); err != nil {
+		return err
+	}
+	if err := setDuration("EMAIL_VERIFICATION_TTL", &c.Accounts.EmailVerificationTTL); err != nil {
+		return err
+	}
+	if err := setDuration("VERIFICATION_RESEND_INTERVAL", &c.Accounts.VerificationResendInterval); err != nil {
+		return err
+	}
+	if err := setDuration("COMMENT_EDIT_WINDOW", &c.Comments.EditWindow); err != nil {
+		return err
+	}
+	if err := setBool("ALLOW_UNVERIFIED_LOGIN", &c.Accounts.AllowUnverifiedLogin); err != nil {
+		return err
+	}
+	if err := setBool("ALLOW_UNVERIFIED_POSTING", &c.Accounts.AllowUnverifiedPosting); err != nil {
+		return err
+	}
+	if value, ok := os.LookupEnv("STORAGE_MAX_UPLOAD_BYTES"); ok {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("config: STORAGE_MAX_UPLOAD_BYTES: %w", err)
+		}
+		c.Storage.MaxUploadBytes = n
+	}
+	if err := setBool("S3_PATH_STYLE", &c.Storage.S3.PathStyle); err != nil {
+		return err
+	}
+	return setBool("CORS_DEBUG", &c.CORS.Debug)
+}
+
+// Validate reports every missing or invalid setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+
+	if !contains(Environments, c.Env) {
+		problems = append(problems, fmt.Sprintf("env must be one of %s, got %q", strings.Join(Environments, ", "), c.Env))
+	}
+	if c.Server.Addr == "" {
+		problems = append(problems, "server.addr (HTTP_ADDR) is required")
+	}
+	if !contains(DatabaseDrivers, c.Database.Driver) {
+		problems = append(problems, fmt.Sprintf("database.driver (DB_DRIVER) must be one of %s, got %q", strings.Join(DatabaseDrivers, ", "), c.Database.Driver))
+	}
+	if c.Server.ReadHeaderTimeout <= 0 || c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		problems = append(problems, "server timeouts must be positive")
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		problems = append(problems, "server.max_header_bytes must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.shutdown_timeout must be positive")
+	}
+	if c.Server.DrainDelay < 0 || c.Server.DrainDelay >= c.Server.ShutdownTimeout {
+		problems = append(problems, "server.drain_delay must be between zero and server.shutdown_timeout")
+	}
+	if c.Database.DSN == "" {
+		problems = append(problems, "database.dsn (DB_DSN) is required")
+	}
+	if c.Auth.JWTSecret == "" {
+		problems = append(problems, "auth.jwt_secret (JWT_SECRET) is required")
+	} else if c.Env == "prod" && len(c.Auth.JWTSecret) < 32 {
+		problems = append(problems, "auth.jwt_secret (JWT_SECRET) must be at least 32 characters in prod")
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		problems = append(problems, "auth.access_token_ttl must be positive")
+	}
+	if c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL {
+		problems = append(problems, "auth.refresh_token_ttl must be longer than auth.access_token_ttl")
+	}
+	if c.Accounts.DeletionGracePeriod < 0 {
+		problems = append(problems, "accounts.deletion_grace_period must not be negative")
+	}
+	if c.Accounts.PasswordResetURL == "" {
+		problems = append(problems, "accounts.password_reset_url (PASSWORD_RESET_URL) is required")
+	}
+	if c.Accounts.PasswordResetTTL <= 0 {
+		problems = append(problems, "accounts.password_reset_ttl must be positive")
+	}
+	if c.Accounts.EmailVerificationURL == "" {
+		problems = append(problems, "accounts.email_verification_url (EMAIL_VERIFICATION_URL) is required")
+	}
+	if c.Accounts.EmailVerificationTTL <= 0 {
+		problems = append(problems, "accounts.email_verification_ttl must be positive")
+	}
+	if c.Accounts.VerificationResendInterval < 0 {
+		problems = append(problems, "accounts.verification_resend_interval must not be negative")
+	}
+	if c.Comments.EditWindow < 0 {
+		problems = append(problems, "comments.edit_window must not be negative")
+	}
+
+	switch c.Mail.Driver {
+	case "smtp":
+		if c.Mail.SMTP.Host == "" || c.Mail.SMTP.Port <= 0 {
+			problems = append(problems, "mail.smtp needs host and port (SMTP_HOST, SMTP_PORT)")
+		}
+	case "file", "log":
+		if c.Env == "prod" {
+			problems = append(problems, fmt.Sprintf("mail.driver %s never delivers mail and is not allowed in prod", c.Mail.Driver))
+		}
+		if c.Mail.Driver == "file" && c.Mail.File.Dir == "" {
+			problems = append(problems, "mail.file.dir (MAIL_FILE_DIR) is required for the file driver")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("mail.driver (MAIL_DRIVER) must be one of %s, got %q", strings.Join(MailDrivers, ", "), c.Mail.Driver))
+	}
+	if c.Mail.From == "" {
+		problems = append(problems, "mail.from (MAIL_FROM) is required")
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		problems = append(problems, "cors.allowed_origins (CORS_ALLOWED_ORIGINS) needs at least one origin")
+	}
+	if c.Env == "prod" && c.CORS.Debug {
+		problems = append(problems, "cors.debug must be disabled in prod")
+	}
+
+	switch c.Storage.Driver {
+	case "local":
+		if c.Storage.Local.Dir == "" {
+			problems = append(problems, "storage.local.dir (STORAGE_LOCAL_DIR) is required for the local driver")
+		}
+	case "s3":
+		s3 := c.Storage.S3
+		if s3.Endpoint == "" || s3.Region == "" || s3.Bucket == "" || s3.AccessKeyID == "" || s3.SecretAccessKey == "" {
+			problems = append(problems, "storage.s3 needs endpoint, region, bucket, access_key_id and secret_access_key (S3_*)")
+		}
+	case "memory":
+		if c.Env == "prod" {
+			problems = append(problems, "storage.driver memory loses every upload on restart and is not allowed in prod")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("storage.driver (STORAGE_DRIVER) must be one of %s, got %q", strings.Join(StorageDrivers, ", "), c.Storage.Driver))
+	}
+	if c.Storage.PublicURL == "" {
+		problems = append(problems, "storage.public_url (STORAGE_PUBLIC_URL) is required")
+	}
+	if c.Storage.MaxUploadBytes <= 0 {
+		problems = append(problems, "storage.max_upload_bytes (STORAGE_MAX_UPLOAD_BYTES) must be positive")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("config: invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// splitList splits a comma separated value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"github.com/gorilla/mux"
+)
+
+// Page sizes of GET /api/admin/users.
+const (
+	defaultUsersPerPage = 20
+	maxUsersPerPage     = 100
+)
+
+// ListRoles returns every role and the permissions it grants.
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles := make([]models.RoleModel, 0, len(models.Roles))
+	for _, role := range models.Roles {
+		roles = append(roles, models.RoleModel{Name: role, Permissions: models.RolePermissions(role)})
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Roles retrieved successfully",
+		Data:    roles,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// ListUsers returns a page of accounts, optionally only those whose name
+// or email contains the q query parameter.
+//
+// Pages are selected with the page and per_page query parameters, which
+// default to 1 and 20.
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, ok := queryInt(query.Get("page"), 1)
+	if !ok || page < 1 {
+		respondWithError(w, http.StatusBadRequest, "page must be a positive number")
+		return
+	}
+	perPage, ok := queryInt(query.Get("per_page"), defaultUsersPerPage)
+	if !ok || perPage < 1 || perPage > maxUsersPerPage {
+		respondWithError(w, http.StatusBadRequest, "per_page must be between 1 and "+strconv.Itoa(maxUsersPerPage))
+		return
+	}
+
+	users, total, err := h.users.Search(r.Context(), strings.TrimSpace(query.Get("q")), (page-1)*perPage, perPage)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve users")
+		return
+	}
+
+	list := models.UserListModel{Users: make([]models.AdminUserModel, 0, len(users)), Page: page, PerPage: perPage, Total: total}
+	for _, user := range users {
+		list.Users = append(list.Users, h.adminUserModel(user))
+	}
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Users retrieved successfully",
+		Data:    list,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// GetUser returns one account along with how many stories it has.
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+	count, err := h.stories.CountByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	model := h.adminUserModel(user)
+	model.StoryCount = &count
+	successResponse := models.Response{
+		Status:  true,
+		Message: "User retrieved successfully",
+		Data:    model,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// DisableUser locks an account out: it ends every session and, until the
+// account is enabled again, refuses its logins and tokens.
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadOtherUser(w, r, "disable")
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if user.DisabledAt == nil {
+		now := time.Now().UTC()
+		if err := h.revocations.setDisabled(ctx, user.ID, &now); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to disable user")
+			return
+		}
+		user.DisabledAt = &now
+	}
+	if err := h.revokeAllSessions(ctx, user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to disable user")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "User disabled successfully",
+		Data:    h.adminUserModel(user),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// EnableUser lets a disabled account log in again.
+func (h *Handler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.revocations.setDisabled(r.Context(), user.ID, nil); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to enable user")
+		return
+	}
+
+	user.DisabledAt = nil
+	successResponse := models.Response{
+		Status:  true,
+		Message: "User enabled successfully",
+		Data:    h.adminUserModel(user),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// LogoutUser ends every session of an account.
+func (h *Handler) LogoutUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.revokeAllSessions(r.Context(), user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to log out user")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "User logged out from all sessions successfully",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// UpdateUserRole gives an account another role.
+//
+// Access tokens carry the role they were issued with, so every session of
+// the account is ended and the new role applies from its next login.
+func (h *Handler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !models.ValidRole(req.Role) {
+		respondWithError(w, http.StatusBadRequest, "Role must be one of "+strings.Join(models.Roles, ", "))
+		return
+	}
+
+	user, ok := h.loadOtherUser(w, r, "change the role of")
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if req.Role != user.Role {
+		if err := h.users.UpdateRole(ctx, user.ID, req.Role); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update role")
+			return
+		}
+		if err := h.revokeAllSessions(ctx, user.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update role")
+			return
+		}
+		user.Role = req.Role
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Role updated successfully",
+		Data:    h.adminUserModel(user),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// DeleteUser purges an account straight away, without the grace period
+// owners get when they delete their own account.
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadOtherUser(w, r, "delete")
+	if !ok {
+		return
+	}
+
+	if err := h.purgeAccount(r.Context(), user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "User deleted successfully",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// loadUser reads the user named by the {id} route variable. It writes a
+// 400, 404 or 500 error response and returns false when that fails.
+func (h *Handler) loadUser(w http.ResponseWriter, r *http.Request) (models.RegisterUserModel, bool) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || userID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return models.RegisterUserModel{}, false
+	}
+
+	user, err := h.users.FindByID(r.Context(), userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return user, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load user")
+		return user, false
+	}
+	return user, true
+}
+
+// loadOtherUser is loadUser for actions an admin must not apply to their
+// own account, which could lock every admin out. action completes the
+// sentence "You cannot ... your own account".
+func (h *Handler) loadOtherUser(w http.ResponseWriter, r *http.Request, action string) (models.RegisterUserModel, bool) {
+	user, ok := h.loadUser(w, r)
+	if ok && user.ID == currentPrincipal(r).UserID {
+		respondWithError(w, http.StatusBadRequest, "You cannot "+action+" your own account")
+		return user, false
+	}
+	return user, ok
+}
+
+// adminUserModel builds the view of an account returned to admins.
+func (h *Handler) adminUserModel(user models.RegisterUserModel) models.AdminUserModel {
+	return models.AdminUserModel{
+		ID:                  user.ID,
+		FullName:            user.FullName,
+		Email:               user.Email,
+		ProfilePic:          h.profilePicURL(user.ProfilePic),
+		Role:                user.Role,
+		EmailVerified:       user.EmailVerifiedAt != nil,
+		DisabledAt:          user.DisabledAt,
+		DeletionRequestedAt: user.DeletedAt,
+	}
+}
+
+// queryInt parses an optional integer query parameter, returning def
+// when it is empty.
+func queryInt(value string, def int) (int, bool) {
+	if value == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
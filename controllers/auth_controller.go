@@ -1,225 +1,220 @@
 package controllers
 
 import (
-	"database/sql"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
+	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/storage"
 	"blog_project.com/utils"
 )
 
-var db *sql.DB
-
-// Initialize sets the database connection
-//
-// This function should be called at the start of the application
-// to set up the database connection that will be used by the
-// controllers for user management.
-func Initialize(database *sql.DB) {
-	db = database
-}
-
-func CreateUser(w http.ResponseWriter, r *http.Request) {
-    // Limit the size of the request body
-    r.ParseMultipartForm(10 << 20) // 10MB max file size
-
-    // Parse the multipart form
-    fullName := r.FormValue("full_name")
-    email := r.FormValue("email")
-    password := r.FormValue("password")
+// CreateUser registers a new user from a multipart form with the
+// full_name, email, password and profile_pic fields.
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	// Limit the size of the request body and parse the multipart form
+	if err := h.uploads.ParseForm(w, r); err != nil {
+		h.respondWithUploadError(w, err)
+		return
+	}
 
+	// Parse the multipart form
+	fullName := r.FormValue("full_name")
+	email := r.FormValue("email")
+	password := r.FormValue("password")
 
 	if fullName == "" {
-        respondWithError(w, http.StatusBadRequest, "Full name is mandatory")
-        return
-    }
-    if email == "" {
-        respondWithError(w, http.StatusBadRequest, "Email is mandatory")
-        return
-    }
-    if password == "" {
-        respondWithError(w, http.StatusBadRequest, "Password is mandatory")
-        return
-    }
-    // Get the file from the form input
-    file, handler, err := r.FormFile("profile_pic")
-    if err != nil {
-        respondWithError(w, http.StatusBadRequest, "Profile picture is mandatory")
-        return
-    }
-    defer file.Close()
-
-    // Create unique file name with timestamp
-    fileName := fmt.Sprintf("uploads/%d-%s", time.Now().Unix(), handler.Filename)
-
-    // Save the file to the server
-    outFile, err := os.Create(fileName)
-    if err != nil {
-        respondWithError(w, http.StatusInternalServerError, "Failed to save profile picture")
-        return
-    }
-    defer outFile.Close()
-
-    // Copy the uploaded file's content to the new file
-    _, err = io.Copy(outFile, file)
-    if err != nil {
-        respondWithError(w, http.StatusInternalServerError, "Failed to store profile picture")
-        return
-    }
+		respondWithError(w, http.StatusBadRequest, "Full name is mandatory")
+		return
+	}
+	if email == "" {
+		respondWithError(w, http.StatusBadRequest, "Email is mandatory")
+		return
+	}
+	if password == "" {
+		respondWithError(w, http.StatusBadRequest, "Password is mandatory")
+		return
+	}
+	// Store the profile picture and its thumbnails under a random key
+	fileName, err := h.saveProfilePicture(r.Context(), w, r, "profile_pic")
+	if errors.Is(err, storage.ErrMissingFile) {
+		respondWithError(w, http.StatusBadRequest, "Profile picture is mandatory")
+		return
+	}
+	if err != nil {
+		h.respondWithUploadError(w, err)
+		return
+	}
 
-    // Hash the password
-    hashedPassword, err := utils.HashPassword(password)
-    if err != nil {
-        respondWithError(w, http.StatusInternalServerError, "Failed to hash password")
-        return
-    }
+	// Hash the password
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
 
-    // Insert user into the database
-    result, err := db.Exec("INSERT INTO users (full_name, email, password, profile_pic) VALUES (?, ?, ?, ?)",
-        fullName, email, hashedPassword, fileName)
-    if err != nil {
-        respondWithError(w, http.StatusConflict, "Email ID already exists")
-        return
-    }
+	// Insert user into the database
+	userId, err := h.users.Create(r.Context(), models.RegisterUserModel{
+		FullName:   fullName,
+		Email:      email,
+		Password:   hashedPassword,
+		ProfilePic: fileName,
+	})
+	if err != nil {
+		// Don't leave the uploaded picture behind for a failed registration
+		h.deleteProfilePicture(r.Context(), fileName)
+	}
+	if errors.Is(err, repositories.ErrDuplicateEmail) {
+		respondWithError(w, http.StatusConflict, "Email ID already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to register user")
+		return
+	}
 
-    // Retrieve the new user ID
-    userId, err := result.LastInsertId()
-    if err != nil {
-        respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user ID")
-        return
-    }
+	// Ask the new user to confirm their email; the account is usable
+	// anyway, so a mail failure does not fail the registration
+	newUser := models.RegisterUserModel{ID: userId, FullName: fullName, Email: email, Role: models.RoleUser}
+	if err := h.sendVerification(r.Context(), newUser, false); err != nil {
+		log.Printf("Failed to send verification mail to user %d: %v", userId, err)
+	}
 
-    // Generate a token for the user
-    token, err := utils.GenerateToken(int(userId), email)
-    if err != nil {
-        respondWithError(w, http.StatusInternalServerError, "Failed to generate token, please try again")
-        return
-    }
+	// Generate an access token and a refresh token for the user, unless
+	// the configuration keeps unverified accounts from logging in
+	message := "User registration successful, please verify your email to log in"
+	var token, refreshToken string
+	if h.opts.AllowUnverifiedLogin {
+		token, refreshToken, err = h.issueSession(r.Context(), newUser, "")
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to generate token, please try again")
+			return
+		}
+		message = "User registration successful"
+	}
 
-    // Build success response
-    successResponse := models.Response{
-        Status:  true,
-        Message: "User registration successful",
-        Data: map[string]interface{}{
-            "id":          userId,
-            "full_name":   fullName,
-            "email":       email,
-            "profile_pic": fileName,
-        },
-        Token: token,
-    }
+	// Build success response
+	successResponse := models.Response{
+		Status:  true,
+		Message: message,
+		Data: map[string]interface{}{
+			"id":                   userId,
+			"full_name":            fullName,
+			"email":                email,
+			"profile_pic":          h.profilePicURL(fileName),
+			"profile_pic_variants": h.profilePicVariants(fileName),
+			"email_verified":       false,
+			"role":                 newUser.Role,
+		},
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
 
-    respondWithJSON(w, http.StatusOK, successResponse)
+	respondWithJSON(w, http.StatusOK, successResponse)
 }
 
-
-
 // LoginUser handles user login.
 //
 // It decodes the incoming request body to extract login credentials,
 // validates the input, retrieves the user from the database,
 // checks the password, generates a token, and returns a JSON
 // response with user details and a success message.
-func LoginUser(w http.ResponseWriter, r *http.Request) {
-    var user models.LoginUserModel
-    if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-        respondWithError(w, http.StatusBadRequest, "Invalid request payload")
-        return
-    }
-
-    // Validate input fields
-    errors := utils.ValidateUserInput(user, false) // false for login
-    if len(errors) > 0 {
-        respondWithError(w, http.StatusBadRequest, utils.ErrorMessages(errors))
-        return
-    }
-
-    // Retrieve user from database
-    var dbUser models.RegisterUserModel
-    err := db.QueryRow("SELECT id, full_name, email, profile_pic, password FROM users WHERE email = ?", user.Email).Scan(
-        &dbUser.ID, &dbUser.FullName, &dbUser.Email, &dbUser.ProfilePic, &dbUser.Password,
-    )
-    if err != nil {
-        respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
-        return
-    }
-
-    // Check password
-    if err := utils.CheckPasswordHash(user.Password, dbUser.Password); err != nil {
-        respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
-        return
-    }
-
-    // Generate token
-    token, err := utils.GenerateToken(dbUser.ID, dbUser.Email)
-    if err != nil {
-        respondWithError(w, http.StatusInternalServerError, "Failed to generate token, please try again")
-        return
-    }
-
-    // Construct the full URL or file path for the profile picture
-    profilePicURL := fmt.Sprintf("/uploads/%s", dbUser.ProfilePic) // Adjust if needed for full URL
-
-    // Prepare login response with profile_pic URL
-    loginResponse := models.LoginResponse{
-        ID:         dbUser.ID,
-        FullName:   dbUser.FullName,
-        Email:      dbUser.Email,
-        ProfilePic: profilePicURL,  // Return the profile picture URL or file path
-    }
-
-    // Send success response
-    successResponse := models.Response{
-        Status:  true,
-        Message: "Login successful",
-        Data:    loginResponse,
-        Token:   token,
-    }
-    respondWithJSON(w, http.StatusOK, successResponse)
-}
+func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
+	var user models.LoginUserModel
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
 
+	// Validate input fields
+	validationErrors := utils.ValidateUserInput(user, false) // false for login
+	if len(validationErrors) > 0 {
+		respondWithError(w, http.StatusBadRequest, utils.ErrorMessages(validationErrors))
+		return
+	}
 
+	// Retrieve user from database
+	dbUser, err := h.users.FindByEmail(r.Context(), user.Email)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
 
-// GetUserProfile retrieves the user's profile data based on the user ID
-func GetUserProfile(w http.ResponseWriter, r *http.Request) {
-	var userID int
-	var err error
+	// Check password
+	if err := utils.CheckPasswordHash(user.Password, dbUser.Password); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
 
-	// Extract user ID from the token
-	tokenString := r.Header.Get("Authorization")
-	if tokenString == "" {
-		http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+	// Disabled accounts stay locked out until an admin enables them
+	if dbUser.DisabledAt != nil {
+		respondWithError(w, http.StatusForbidden, "Your account has been disabled")
 		return
 	}
 
-	// Remove "Bearer " from tokenString and trim spaces
-	tokenString = strings.TrimSpace(strings.TrimPrefix(tokenString, "Bearer "))
-	if tokenString == "" {
-		http.Error(w, "Invalid token format", http.StatusUnauthorized)
+	// Depending on the configuration, unverified accounts cannot log in
+	if dbUser.EmailVerifiedAt == nil && !h.opts.AllowUnverifiedLogin {
+		respondWithError(w, http.StatusForbidden, "Please verify your email before logging in")
 		return
 	}
-	if tokenString != "" {
-		userID, err =utils.ParseToken(tokenString)
-		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+
+	// Logging in during the deletion grace period cancels the deletion
+	message := "Login successful"
+	if dbUser.DeletedAt != nil {
+		if err := h.users.SetDeletedAt(r.Context(), dbUser.ID, nil); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to restore account, please try again")
 			return
 		}
-	} else {
-		respondWithError(w, http.StatusUnauthorized, "Missing authorization token")
+		message = "Login successful, account deletion cancelled"
+	}
+
+	// Generate an access token and a refresh token
+	token, refreshToken, err := h.issueSession(r.Context(), dbUser, "")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to generate token, please try again")
 		return
 	}
 
+	// Construct the URL for the profile picture
+	profilePicURL := h.profilePicURL(dbUser.ProfilePic)
+
+	// Prepare login response with profile_pic URL
+	loginResponse := models.LoginResponse{
+		ID:         dbUser.ID,
+		FullName:   dbUser.FullName,
+		Email:      dbUser.Email,
+		ProfilePic: profilePicURL, // Return the profile picture URL
+		// Along with the URLs of its square thumbnails
+		ProfilePicVariants: h.profilePicVariants(dbUser.ProfilePic),
+		EmailVerified:      dbUser.EmailVerifiedAt != nil,
+		Role:               dbUser.Role,
+	}
+
+	// Send success response
+	successResponse := models.Response{
+		Status:       true,
+		Message:      message,
+		Data:         loginResponse,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// GetUserProfile retrieves the user's profile data based on the user ID
+func (h *Handler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	userID := currentPrincipal(r).UserID
+
 	// Now you can retrieve the user data based on userID
-	var user models.GetUserProfileModel
-	err = db.QueryRow("SELECT full_name, email, profile_pic FROM users WHERE id = ?", userID).Scan(
-		&user.FullName, &user.Email, &user.ProfilePic,
-	)
+	dbUser, err := h.users.FindByID(r.Context(), userID)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "User not found")
 		return
@@ -229,7 +224,7 @@ func GetUserProfile(w http.ResponseWriter, r *http.Request) {
 	successResponse := models.Response{
 		Status:  true,
 		Message: "User profile retrieved successfully",
-		Data:    user,
+		Data:    h.profileModel(dbUser),
 	}
 
 	respondWithJSON(w, http.StatusOK, successResponse)
@@ -246,6 +241,35 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	json.NewEncoder(w).Encode(payload)
 }
 
+// respondWithCachedJSON sends a 200 JSON response that clients and shared
+// caches may reuse for maxAge.
+//
+// The ETag is a hash of the body, so a request whose If-None-Match header
+// names it is answered with an empty 304 Not Modified instead.
+func respondWithCachedJSON(w http.ResponseWriter, r *http.Request, payload interface{}, maxAge time.Duration) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge/time.Second)))
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
+
 // respondWithError sends an error response in JSON format.
 //
 // This utility function uses the standard response structure
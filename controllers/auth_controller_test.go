@@ -0,0 +1,127 @@
+package controllers_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"blog_project.com/controllers"
+)
+
+// registration returns the multipart body of a registration with a
+// small PNG profile picture, along with its content type.
+func registration(t *testing.T, fullName, email, password string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("full_name", fullName)
+	form.WriteField("email", email)
+	form.WriteField("password", password)
+
+	picture := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	picture.Set(1, 1, color.RGBA{R: 255, A: 255})
+	part, err := form.CreateFormFile("profile_pic", "me.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(part, picture); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &body, form.FormDataContentType()
+}
+
+var verificationLink = regexp.MustCompile(`http://api\.test/api/verify-email\?\S+`)
+
+func TestRegisterVerifyAndLogin(t *testing.T) {
+	forEachBackend(t, controllers.Options{}, func(t *testing.T, s *testServer) {
+		credentials := map[string]string{"email": "dana@example.com", "password": "secret123"}
+
+		body, contentType := registration(t, "Dana Scully", "dana@example.com", "secret123")
+		code, resp := s.send("POST", "/api/register", "", contentType, body)
+		if code != http.StatusOK || resp.Token != "" || resp.RefreshToken != "" {
+			t.Fatalf("register = %d %q with token %q, want 200 without tokens", code, resp.Message, resp.Token)
+		}
+
+		body, contentType = registration(t, "Someone Else", "DANA@example.com", "other1234")
+		if code, resp := s.send("POST", "/api/register", "", contentType, body); code != http.StatusConflict {
+			t.Errorf("register with a taken email = %d %q, want 409", code, resp.Message)
+		}
+
+		if code, resp := s.do("POST", "/api/login", "", credentials); code != http.StatusForbidden {
+			t.Errorf("login before verifying = %d %q, want 403", code, resp.Message)
+		}
+
+		msg, ok := s.mailer.last("dana@example.com")
+		if !ok {
+			t.Fatal("no verification mail was sent")
+		}
+		link, err := url.Parse(verificationLink.FindString(msg.Body))
+		if err != nil || link.Query().Get("token") == "" {
+			t.Fatalf("no verification link in %q", msg.Body)
+		}
+		s.expect(http.StatusOK, "GET", "/api/verify-email?token="+url.QueryEscape(link.Query().Get("token")), "", nil)
+
+		if code, resp := s.do("POST", "/api/login", "", map[string]string{"email": "dana@example.com", "password": "wrong"}); code != http.StatusUnauthorized {
+			t.Errorf("login with a wrong password = %d %q, want 401", code, resp.Message)
+		}
+		resp = s.expect(http.StatusOK, "POST", "/api/login", "", credentials)
+		if resp.Token == "" || resp.RefreshToken == "" {
+			t.Fatalf("login returned token %q and refresh token %q", resp.Token, resp.RefreshToken)
+		}
+
+		var profile struct {
+			Email         string `json:"email"`
+			EmailVerified bool   `json:"email_verified"`
+			Role          string `json:"role"`
+		}
+		s.expect(http.StatusOK, "GET", "/api/profile", resp.Token, nil).decode(t, &profile)
+		if profile.Email != "dana@example.com" || !profile.EmailVerified || profile.Role != "user" {
+			t.Errorf("profile = %+v", profile)
+		}
+	})
+}
+
+func TestProtectedRoutesNeedAToken(t *testing.T) {
+	forEachBackend(t, controllers.Options{}, func(t *testing.T, s *testServer) {
+		for _, token := range []string{"", "not-a-token"} {
+			if code, resp := s.do("GET", "/api/profile", token, nil); code != http.StatusUnauthorized {
+				t.Errorf("GET /api/profile with token %q = %d %q, want 401", token, code, resp.Message)
+			}
+		}
+	})
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	forEachBackend(t, controllers.Options{}, func(t *testing.T, s *testServer) {
+		s.createUser("fox@example.com", "user")
+		login := s.expect(http.StatusOK, "POST", "/api/login", "", map[string]string{"email": "fox@example.com", "password": "password1"})
+
+		refreshed := s.expect(http.StatusOK, "POST", "/api/token/refresh", "", map[string]string{"refresh_token": login.RefreshToken})
+		if refreshed.Token == "" || refreshed.RefreshToken == "" || refreshed.RefreshToken == login.RefreshToken {
+			t.Fatalf("refresh returned token %q and refresh token %q", refreshed.Token, refreshed.RefreshToken)
+		}
+		if code, resp := s.do("POST", "/api/token/refresh", "", map[string]string{"refresh_token": login.RefreshToken}); code != http.StatusUnauthorized {
+			t.Errorf("reusing a refresh token = %d %q, want 401", code, resp.Message)
+		}
+
+		s.expect(http.StatusOK, "POST", "/api/logout-all", refreshed.Token, nil)
+		for _, token := range []string{login.Token, refreshed.Token} {
+			if code, resp := s.do("GET", "/api/profile", token, nil); code != http.StatusUnauthorized {
+				t.Errorf("token after logout-all = %d %q, want 401", code, resp.Message)
+			}
+		}
+
+		// A session started right after logging out everywhere is valid.
+		token := s.login("fox@example.com")
+		s.expect(http.StatusOK, "GET", "/api/profile", token, nil)
+	})
+}
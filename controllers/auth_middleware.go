@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"blog_project.com/models"
+	"blog_project.com/utils"
+)
+
+// AuthMiddleware authenticates every request routed through it.
+//
+// It expects a Bearer token in the Authorization header, validates it
+// with utils.ParseClaims and stores the resulting principal in the
+// request context, where handlers read it with currentPrincipal.
+// Requests without a valid token are answered with a JSON 401 and a
+// WWW-Authenticate challenge and never reach the wrapped handler.
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			respondUnauthorized(w, "", "Missing authorization token")
+			return
+		}
+
+		scheme, tokenString, found := strings.Cut(strings.TrimSpace(header), " ")
+		tokenString = strings.TrimSpace(tokenString)
+		if !found || !strings.EqualFold(scheme, "Bearer") || tokenString == "" {
+			respondUnauthorized(w, "invalid_request", "Invalid token format")
+			return
+		}
+
+		principal, err := utils.ParseClaims(tokenString)
+		if errors.Is(err, utils.ErrAccountDisabled) {
+			respondUnauthorized(w, "invalid_token", "Your account has been disabled")
+			return
+		}
+		if err != nil {
+			respondUnauthorized(w, "invalid_token", "Invalid or expired token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(utils.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequirePermission returns a middleware that only lets through requests
+// whose principal has a role granting permission; the others are
+// answered with a JSON 403.
+//
+// It reads the principal stored by AuthMiddleware, so it must be used on
+// routes that AuthMiddleware already protects. Roles are taken from the
+// access token, so a role change applies once the user's tokens are
+// refreshed.
+func RequirePermission(permission models.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !currentPrincipal(r).Can(permission) {
+				respondWithError(w, http.StatusForbidden, "You do not have permission to perform this action")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// currentPrincipal returns the principal stored by AuthMiddleware.
+//
+// Handlers mounted behind the middleware can rely on it being present;
+// the zero Principal is returned otherwise.
+func currentPrincipal(r *http.Request) models.Principal {
+	principal, _ := utils.PrincipalFromContext(r.Context())
+	return principal
+}
+
+// respondUnauthorized sends a 401 in the standard response format along
+// with a Bearer challenge as described in RFC 6750.
+func respondUnauthorized(w http.ResponseWriter, errorCode, message string) {
+	challenge := `Bearer realm="api"`
+	if errorCode != "" {
+		challenge += `, error="` + errorCode + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	respondWithError(w, http.StatusUnauthorized, message)
+}
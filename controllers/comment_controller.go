@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"github.com/gorilla/mux"
+)
+
+// Page sizes of GET /api/stories/{id}/comments.
+const (
+	defaultCommentsPerPage = 20
+	maxCommentsPerPage     = 100
+)
+
+// AddComment comments on a published story as the authenticated user, or
+// replies to a comment on it when parent_id is set. Replies cannot be
+// replied to in turn.
+func (h *Handler) AddComment(w http.ResponseWriter, r *http.Request) {
+	if !h.requireVerifiedEmail(w, r) {
+		return
+	}
+	story, ok := h.loadPublishedStory(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AddCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	body, ok := requireCommentBody(w, req.Body)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if req.ParentID != 0 {
+		parent, err := h.comments.FindByID(ctx, req.ParentID)
+		if errors.Is(err, repositories.ErrNotFound) || (err == nil && (parent.StoryID != story.ID || parent.Removed())) {
+			respondWithError(w, http.StatusBadRequest, "parent_id must be a comment on this story")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve comment")
+			return
+		}
+		if parent.ParentID != 0 {
+			respondWithError(w, http.StatusBadRequest, "Replies cannot be replied to")
+			return
+		}
+	}
+
+	commentID, err := h.comments.Create(ctx, models.Comment{
+		StoryID:   story.ID,
+		ParentID:  req.ParentID,
+		UserID:    currentPrincipal(r).UserID,
+		Body:      body,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to store comment")
+		return
+	}
+	comment, err := h.comments.FindByID(ctx, commentID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve comment")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Comment added successfully",
+		Data:    h.commentModel(comment, false),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// ListComments returns a page of the comments on a published story,
+// oldest first, each with its replies. It needs no authentication.
+//
+// Pages hold per_page (20 by default) top-level comments; the total
+// counts those too. Deleted and hidden comments are only listed, without
+// their text, when replies to them are.
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, ok := queryInt(query.Get("page"), 1)
+	if !ok || page < 1 {
+		respondWithError(w, http.StatusBadRequest, "page must be a positive number")
+		return
+	}
+	perPage, ok := queryInt(query.Get("per_page"), defaultCommentsPerPage)
+	if !ok || perPage < 1 || perPage > maxCommentsPerPage {
+		respondWithError(w, http.StatusBadRequest, "per_page must be between 1 and "+strconv.Itoa(maxCommentsPerPage))
+		return
+	}
+
+	story, ok := h.loadPublishedStory(w, r)
+	if !ok {
+		return
+	}
+	comments, total, err := h.comments.ListByStory(r.Context(), story.ID, (page-1)*perPage, perPage)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve comments")
+		return
+	}
+
+	list := models.CommentListModel{Comments: make([]models.CommentModel, 0, len(comments)), Page: page, PerPage: perPage, Total: total}
+	for _, comment := range comments {
+		list.Comments = append(list.Comments, h.commentModel(comment, false))
+	}
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Comments retrieved successfully",
+		Data:    list,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// UpdateComment replaces the text of a comment of the authenticated user,
+// within the edit window after posting it.
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	body, ok := requireCommentBody(w, req.Body)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.loadEditableComment(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := h.comments.UpdateBody(r.Context(), comment.ID, body, now); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update comment")
+		return
+	}
+	comment.Body, comment.EditedAt = body, &now
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Comment updated successfully",
+		Data:    h.commentModel(comment, false),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// DeleteComment deletes a comment of the authenticated user, within the
+// edit window after posting it. Replies to it stay.
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	comment, ok := h.loadEditableComment(w, r)
+	if !ok {
+		return
+	}
+	if err := h.comments.Delete(r.Context(), comment.ID, time.Now().UTC()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete comment")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Comment deleted successfully",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// HideComment hides a comment from everyone. Replies to it stay.
+func (h *Handler) HideComment(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	h.setCommentHidden(w, r, &now, "Comment hidden")
+}
+
+// UnhideComment shows a hidden comment again.
+func (h *Handler) UnhideComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentHidden(w, r, nil, "Comment shown again")
+}
+
+// setCommentHidden implements HideComment and UnhideComment. The
+// response includes the text of the comment, for moderators.
+func (h *Handler) setCommentHidden(w http.ResponseWriter, r *http.Request, at *time.Time, message string) {
+	comment, ok := h.loadComment(w, r)
+	if !ok {
+		return
+	}
+	if err := h.comments.SetHiddenAt(r.Context(), comment.ID, at); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update comment")
+		return
+	}
+	comment.HiddenAt = at
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: message,
+		Data:    h.commentModel(comment, true),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// loadComment reads the comment named by the {id} route variable. It
+// writes a 400, 404 or 500 error response and returns false when that
+// fails.
+func (h *Handler) loadComment(w http.ResponseWriter, r *http.Request) (models.Comment, bool) {
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || commentID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return models.Comment{}, false
+	}
+
+	comment, err := h.comments.FindByID(r.Context(), commentID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Comment not found")
+		return models.Comment{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve comment")
+		return models.Comment{}, false
+	}
+	return comment, true
+}
+
+// loadEditableComment is loadComment for changes by the author of the
+// comment, who may only make them within the edit window and not once a
+// moderator hid it. Deleted comments are answered with a 404.
+func (h *Handler) loadEditableComment(w http.ResponseWriter, r *http.Request) (models.Comment, bool) {
+	comment, ok := h.loadComment(w, r)
+	if !ok {
+		return comment, false
+	}
+	if comment.DeletedAt != nil {
+		respondWithError(w, http.StatusNotFound, "Comment not found")
+		return comment, false
+	}
+	if comment.UserID != currentPrincipal(r).UserID {
+		respondWithError(w, http.StatusForbidden, "You can only change your own comments")
+		return comment, false
+	}
+	if comment.HiddenAt != nil {
+		respondWithError(w, http.StatusForbidden, "This comment was hidden by a moderator")
+		return comment, false
+	}
+	if time.Since(comment.CreatedAt) > h.opts.CommentEditWindow {
+		respondWithError(w, http.StatusForbidden, "This comment can no longer be edited or deleted")
+		return comment, false
+	}
+	return comment, true
+}
+
+// requireCommentBody trims the text of a comment. It writes a 400 error
+// response and returns false when the text is empty or too long.
+func requireCommentBody(w http.ResponseWriter, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		respondWithError(w, http.StatusBadRequest, "body must not be empty")
+		return "", false
+	}
+	if utf8.RuneCountInString(body) > models.MaxCommentLen {
+		respondWithError(w, http.StatusBadRequest, "body must be at most "+strconv.Itoa(models.MaxCommentLen)+" characters long")
+		return "", false
+	}
+	return body, true
+}
+
+// commentModel returns the API view of a comment and its replies. The
+// text and author of deleted and hidden comments are left out, unless
+// full is set; deleted comments no longer have a text.
+func (h *Handler) commentModel(comment models.Comment, full bool) models.CommentModel {
+	model := models.CommentModel{
+		ID:        comment.ID,
+		StoryID:   comment.StoryID,
+		ParentID:  comment.ParentID,
+		Deleted:   comment.DeletedAt != nil,
+		Hidden:    comment.HiddenAt != nil,
+		CreatedAt: comment.CreatedAt,
+		EditedAt:  comment.EditedAt,
+	}
+	if full || !comment.Removed() {
+		model.Body = comment.Body
+		if comment.UserID != 0 {
+			model.Author = &models.StoryAuthorModel{
+				ID:                 comment.UserID,
+				FullName:           comment.AuthorName,
+				ProfilePic:         h.profilePicURL(comment.AuthorProfilePic),
+				ProfilePicVariants: h.profilePicVariants(comment.AuthorProfilePic),
+			}
+		}
+	}
+	for _, reply := range comment.Replies {
+		model.Replies = append(model.Replies, h.commentModel(reply, full))
+	}
+	return model
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"blog_project.com/mail"
+	"blog_project.com/repositories"
+	"blog_project.com/storage"
+	"blog_project.com/storytypes"
+	"blog_project.com/utils"
+)
+
+// Handler serves the HTTP API.
+//
+// Every route is a method on Handler, which reaches storage only through
+// the repositories it was constructed with. Swapping the MySQL
+// repositories for the in-memory ones is enough to exercise the handlers
+// with httptest.
+type Handler struct {
+	users    repositories.UserRepository
+	stories  repositories.StoryRepository
+	tags     repositories.TagRepository
+	comments repositories.CommentRepository
+	tokens   repositories.TokenRepository
+	resets   repositories.PasswordResetRepository
+	search   repositories.Searcher
+	blobs    storage.Blob
+	uploads  *storage.Uploader
+	mailer   mail.Mailer
+	types    *storytypes.Registry
+	opts     Options
+
+	revocations *tokenRevocationList
+}
+
+// Options holds the settings handlers need beyond their dependencies.
+type Options struct {
+	// DeletionGracePeriod is how long a deleted account is kept before it
+	// is purged. Zero purges it as soon as its owner deletes it.
+	DeletionGracePeriod time.Duration
+	// PasswordResetURL is the page password reset mails link to; the
+	// token is added as the token query parameter.
+	PasswordResetURL string
+	// PasswordResetTTL is how long a password reset token stays valid.
+	PasswordResetTTL time.Duration
+	// EmailVerificationURL is the endpoint verification mails link to;
+	// the signed token is added as the token query parameter.
+	EmailVerificationURL string
+	// EmailVerificationTTL is how long a verification link stays valid.
+	EmailVerificationTTL time.Duration
+	// VerificationResendInterval is the shortest time between two
+	// verification mails to the same account.
+	VerificationResendInterval time.Duration
+	// AllowUnverifiedLogin and AllowUnverifiedPosting let accounts log in
+	// and add stories before their email is verified.
+	AllowUnverifiedLogin   bool
+	AllowUnverifiedPosting bool
+	// CommentEditWindow is how long after posting a comment its author
+	// can still edit or delete it.
+	CommentEditWindow time.Duration
+}
+
+// NewHandler creates a Handler on top of the given repositories, taking
+// file uploads through uploads and sending mail through mailer.
+//
+// It also loads the token revocation list and registers it with
+// utils.ParseClaims, so it should be called once at application startup.
+func NewHandler(repos *repositories.Repositories, uploads *storage.Uploader, mailer mail.Mailer, opts Options) (*Handler, error) {
+	h := &Handler{
+		users:       repos.Users,
+		stories:     repos.Stories,
+		tags:        repos.Tags,
+		comments:    repos.Comments,
+		tokens:      repos.Tokens,
+		resets:      repos.PasswordResets,
+		search:      repos.Search,
+		blobs:       uploads.Blob(),
+		uploads:     uploads,
+		mailer:      mailer,
+		types:       storytypes.Default,
+		opts:        opts,
+		revocations: newTokenRevocationList(repos.Tokens, repos.Users),
+	}
+
+	if err := h.revocations.load(context.Background()); err != nil {
+		return nil, fmt.Errorf("loading token revocation list: %w", err)
+	}
+	utils.SetRevocationList(h.revocations)
+	return h, nil
+}
+
+// Run performs the handler's background work until ctx is cancelled:
+// keeping the token revocation list in sync and purging accounts whose
+// deletion grace period is over.
+func (h *Handler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		h.revocations.sync(ctx, revocationSyncInterval)
+	}()
+	go func() {
+		defer wg.Done()
+		h.purgeDeletedAccounts(ctx, accountPurgeInterval)
+	}()
+	wg.Wait()
+}
+
+// Uploads serves stored files by key. Mount it behind http.StripPrefix.
+func (h *Handler) Uploads() http.Handler {
+	return storage.FileServer(h.blobs)
+}
@@ -0,0 +1,230 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"blog_project.com/config"
+	"blog_project.com/controllers"
+	"blog_project.com/mail"
+	"blog_project.com/migrations"
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/routers"
+	"blog_project.com/storage"
+	"blog_project.com/utils"
+)
+
+// testServer serves the API over the in-memory repositories or over a
+// migrated SQLite database.
+type testServer struct {
+	t      *testing.T
+	url    string
+	repos  *repositories.Repositories
+	mailer *mailbox
+}
+
+// mailbox keeps every message sent through it.
+type mailbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (m *mailbox) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// last returns the last message sent to the given address.
+func (m *mailbox) last(to string) (mail.Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return mail.Message{}, false
+}
+
+// up answers every readiness ping.
+type up struct{}
+
+func (up) PingContext(ctx context.Context) error { return nil }
+
+// forEachBackend runs test once on the in-memory repositories and once on
+// SQLite, so the two implementations cannot drift apart unnoticed.
+func forEachBackend(t *testing.T, opts controllers.Options, test func(t *testing.T, s *testServer)) {
+	for _, backend := range []string{"memory", repositories.DriverSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			test(t, newTestServer(t, backend, opts))
+		})
+	}
+}
+
+// openSQLite returns the SQL repositories of a new, migrated SQLite
+// database.
+func openSQLite(t *testing.T) (*repositories.Repositories, controllers.Pinger) {
+	t.Helper()
+	db, err := repositories.Open(repositories.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.New(db, repositories.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return repositories.New(db), db
+}
+
+func newTestServer(t *testing.T, backend string, opts controllers.Options) *testServer {
+	t.Helper()
+	utils.ConfigureTokens("test-secret", 15*time.Minute, 24*time.Hour)
+	if opts.EmailVerificationURL == "" {
+		opts.EmailVerificationURL = "http://api.test/api/verify-email"
+		opts.EmailVerificationTTL = time.Hour
+	}
+	if opts.CommentEditWindow == 0 {
+		opts.CommentEditWindow = 15 * time.Minute
+	}
+
+	var repos *repositories.Repositories
+	var db controllers.Pinger = up{}
+	if backend == repositories.DriverSQLite {
+		repos, db = openSQLite(t)
+	} else {
+		repos = repositories.NewMemory()
+	}
+	blobs := storage.NewMemory("/uploads/")
+	mailer := &mailbox{}
+	h, err := controllers.NewHandler(repos, storage.NewUploader(blobs, 1<<20, storage.ImageTypes), mailer, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	health := controllers.NewHealthHandler(db, blobs, func() bool { return false })
+	server := httptest.NewServer(routers.SetupRouter(&config.Config{}, h, health))
+	t.Cleanup(server.Close)
+	return &testServer{t: t, url: server.URL, repos: repos, mailer: mailer}
+}
+
+// response is the envelope every API response is wrapped in.
+type response struct {
+	Status       bool            `json:"status"`
+	Message      string          `json:"message"`
+	Data         json.RawMessage `json:"data"`
+	Token        string          `json:"token"`
+	RefreshToken string          `json:"refresh_token"`
+}
+
+// decode unmarshals the data of the response into v.
+func (r response) decode(t *testing.T, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		t.Fatalf("decoding %s: %v", r.Data, err)
+	}
+}
+
+// send makes a request with the given content type and bearer token and
+// returns the status code with the decoded response.
+func (s *testServer) send(method, path, token, contentType string, body io.Reader) (int, response) {
+	s.t.Helper()
+	req, err := http.NewRequest(method, s.url+path, body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var decoded response
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		s.t.Fatalf("%s %s: decoding the response: %v", method, path, err)
+	}
+	return resp.StatusCode, decoded
+}
+
+// do sends body, when not nil, as JSON.
+func (s *testServer) do(method, path, token string, body interface{}) (int, response) {
+	s.t.Helper()
+	if body == nil {
+		return s.send(method, path, token, "", nil)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return s.send(method, path, token, "application/json", bytes.NewReader(data))
+}
+
+// expect is do failing the test unless the response has the given
+// status code.
+func (s *testServer) expect(status int, method, path, token string, body interface{}) response {
+	s.t.Helper()
+	code, resp := s.do(method, path, token, body)
+	if code != status {
+		s.t.Fatalf("%s %s = %d %q, want %d", method, path, code, resp.Message, status)
+	}
+	return resp
+}
+
+// createUser stores a verified account with the given role and returns
+// its ID. Its password is "password1".
+func (s *testServer) createUser(email, role string) int {
+	s.t.Helper()
+	ctx := context.Background()
+	hash, err := utils.HashPassword("password1")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	id, err := s.repos.Users.Create(ctx, models.RegisterUserModel{FullName: email, Email: email, Password: hash, Role: role})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if _, err := s.repos.Users.MarkEmailVerified(ctx, id, email, time.Now().UTC()); err != nil {
+		s.t.Fatal(err)
+	}
+	return id
+}
+
+// login returns an access token for an account created by createUser.
+func (s *testServer) login(email string) string {
+	s.t.Helper()
+	resp := s.expect(http.StatusOK, "POST", "/api/login", "", map[string]string{"email": email, "password": "password1"})
+	return resp.Token
+}
+
+func TestReadiness(t *testing.T) {
+	forEachBackend(t, controllers.Options{}, func(t *testing.T, s *testServer) {
+		resp := s.expect(http.StatusOK, "GET", "/readyz", "", nil)
+		var checks map[string]string
+		resp.decode(t, &checks)
+		want := map[string]string{"lifecycle": "ok", "database": "ok", "uploads": "ok"}
+		for name, status := range want {
+			if checks[name] != status {
+				t.Errorf("check %s = %q, want %q", name, checks[name], status)
+			}
+		}
+	})
+}
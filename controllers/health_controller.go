@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/storage"
+)
+
+// readinessTimeout bounds how long a single readiness probe may take.
+const readinessTimeout = 2 * time.Second
+
+// Pinger is implemented by *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthHandler answers load balancer and orchestrator probes.
+//
+// It is deliberately separate from Handler: probes must keep working
+// without authentication, CORS or any repository.
+type HealthHandler struct {
+	db           Pinger
+	blobs        storage.Blob
+	shuttingDown func() bool
+}
+
+// NewHealthHandler returns probes that check db and the upload storage.
+// shuttingDown reports when the server is draining, which makes the
+// readiness probe fail so no new traffic is routed here.
+func NewHealthHandler(db Pinger, blobs storage.Blob, shuttingDown func() bool) *HealthHandler {
+	return &HealthHandler{db: db, blobs: blobs, shuttingDown: shuttingDown}
+}
+
+// Liveness reports that the process is up and serving HTTP.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, models.Response{
+		Status:  true,
+		Message: "ok",
+		Data:    struct{}{},
+	})
+}
+
+// Readiness reports whether the instance can serve traffic: it is not
+// shutting down, the database answers a ping and uploads can be stored.
+//
+// Each check is reported as "ok" or "unavailable" only; the probe needs no
+// authentication, so why a check failed is logged instead.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]string{}
+	ready := true
+	check := func(name string, err error) {
+		if err != nil {
+			log.Printf("Readiness check %s failed: %v", name, err)
+			checks[name] = "unavailable"
+			ready = false
+			return
+		}
+		checks[name] = "ok"
+	}
+
+	if h.shuttingDown() {
+		checks["lifecycle"] = "unavailable"
+		ready = false
+	} else {
+		checks["lifecycle"] = "ok"
+	}
+	check("database", h.db.PingContext(ctx))
+	check("uploads", storage.Check(ctx, h.blobs))
+
+	if !ready {
+		respondWithJSON(w, http.StatusServiceUnavailable, models.Response{
+			Status:  false,
+			Message: "Service is not ready",
+			Data:    checks,
+		})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, models.Response{
+		Status:  true,
+		Message: "Service is ready",
+		Data:    checks,
+	})
+}
+
+// Version reports the build information embedded by the Go toolchain.
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Build information is not available")
+		return
+	}
+
+	build := models.BuildInfo{
+		Module:    info.Main.Path,
+		Version:   info.Main.Version,
+		GoVersion: info.GoVersion,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.CommitTime = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, models.Response{
+		Status:  true,
+		Message: "Build information retrieved successfully",
+		Data:    build,
+	})
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog_project.com/models"
+)
+
+// Page sizes of GET /api/moderation/stories.
+const (
+	defaultStoriesPerPage = 20
+	maxStoriesPerPage     = 100
+)
+
+// SubmitStory sends a draft or rejected story of the authenticated user
+// to review.
+func (h *Handler) SubmitStory(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadAuthoredStory(w, r)
+	if !ok {
+		return
+	}
+	if !h.requireVerifiedEmail(w, r) {
+		return
+	}
+	if story, ok = h.transitionStory(w, r, story, models.StoryPendingReview, ""); !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story submitted for review",
+		Data:    storyDocument(story),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// WithdrawStory takes a story of the authenticated user out of review and
+// makes it a draft again.
+func (h *Handler) WithdrawStory(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadAuthoredStory(w, r)
+	if !ok {
+		return
+	}
+	if story.Status != models.StoryPendingReview {
+		respondWithError(w, http.StatusConflict, "Only stories pending review can be withdrawn")
+		return
+	}
+	if story, ok = h.transitionStory(w, r, story, models.StoryDraft, ""); !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story withdrawn from review",
+		Data:    storyDocument(story),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// GetStoryHistory returns every status change of a story, oldest first.
+func (h *Handler) GetStoryHistory(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+
+	transitions, err := h.stories.ListTransitions(r.Context(), story.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve story history")
+		return
+	}
+	if transitions == nil {
+		transitions = []models.StoryTransition{}
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story history retrieved successfully",
+		Data:    transitions,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// ListModerationStories returns a page of the stories with the status
+// given in the status query parameter, pending_review by default, oldest
+// first so the review queue is worked through in order.
+func (h *Handler) ListModerationStories(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status == "" {
+		status = models.StoryPendingReview
+	}
+	if !models.ValidStoryStatus(status) {
+		respondWithError(w, http.StatusBadRequest, "status must be one of "+strings.Join(models.StoryStatuses, ", "))
+		return
+	}
+	page, ok := queryInt(query.Get("page"), 1)
+	if !ok || page < 1 {
+		respondWithError(w, http.StatusBadRequest, "page must be a positive number")
+		return
+	}
+	perPage, ok := queryInt(query.Get("per_page"), defaultStoriesPerPage)
+	if !ok || perPage < 1 || perPage > maxStoriesPerPage {
+		respondWithError(w, http.StatusBadRequest, "per_page must be between 1 and "+strconv.Itoa(maxStoriesPerPage))
+		return
+	}
+
+	stories, total, err := h.stories.ListByStatus(r.Context(), status, (page-1)*perPage, perPage)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve stories")
+		return
+	}
+
+	list := models.StoryListModel{Stories: make([]map[string]interface{}, 0, len(stories)), Page: page, PerPage: perPage, Total: total}
+	for _, story := range stories {
+		document := storyDocument(story)
+		document["authorId"] = story.UserID
+		list.Stories = append(list.Stories, document)
+	}
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Stories retrieved successfully",
+		Data:    list,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// ApproveStory publishes a story pending review.
+func (h *Handler) ApproveStory(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadReviewedStory(w, r)
+	if !ok {
+		return
+	}
+	if story.Status != models.StoryPendingReview {
+		respondWithError(w, http.StatusConflict, "Only stories pending review can be approved")
+		return
+	}
+	if story, ok = h.transitionStory(w, r, story, models.StoryPublished, ""); !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story approved and published",
+		Data:    storyDocument(story),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// RejectStory rejects a story pending review, or takes down a published
+// one. The reason is recorded and shown to the author in the story's
+// history.
+func (h *Handler) RejectStory(w http.ResponseWriter, r *http.Request) {
+	var req models.RejectStoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		respondWithError(w, http.StatusBadRequest, "Reason is mandatory")
+		return
+	}
+
+	story, ok := h.loadReviewedStory(w, r)
+	if !ok {
+		return
+	}
+	if story, ok = h.transitionStory(w, r, story, models.StoryRejected, strings.TrimSpace(req.Reason)); !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story rejected",
+		Data:    storyDocument(story),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// transitionStory moves story to the status to on behalf of the
+// authenticated user and records the change. It writes a 409 or 500
+// error response and returns false when that is not possible.
+func (h *Handler) transitionStory(w http.ResponseWriter, r *http.Request, story models.Story, to, reason string) (models.Story, bool) {
+	if !models.CanTransition(story.Status, to) {
+		respondWithError(w, http.StatusConflict, "A "+story.Status+" story cannot be moved to "+to)
+		return story, false
+	}
+
+	moved, err := h.stories.Transition(r.Context(), models.StoryTransition{
+		StoryID:   story.ID,
+		From:      story.Status,
+		To:        to,
+		ActorID:   currentPrincipal(r).UserID,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to change story status")
+		return story, false
+	}
+	if !moved {
+		respondWithError(w, http.StatusConflict, "The story status was changed meanwhile, please reload it")
+		return story, false
+	}
+
+	story.Status = to
+	return story, true
+}
+
+// loadAuthoredStory is loadOwnedStory for actions only the author of a
+// story may take.
+func (h *Handler) loadAuthoredStory(w http.ResponseWriter, r *http.Request) (models.Story, bool) {
+	story, ok := h.loadOwnedStory(w, r)
+	if ok && story.UserID != currentPrincipal(r).UserID {
+		respondWithError(w, http.StatusForbidden, "Only the author can do this")
+		return story, false
+	}
+	return story, ok
+}
+
+// loadReviewedStory is loadOwnedStory for moderation decisions, which
+// nobody may take on their own stories.
+func (h *Handler) loadReviewedStory(w http.ResponseWriter, r *http.Request) (models.Story, bool) {
+	story, ok := h.loadOwnedStory(w, r)
+	if ok && story.UserID == currentPrincipal(r).UserID {
+		respondWithError(w, http.StatusForbidden, "You cannot review your own story")
+		return story, false
+	}
+	return story, ok
+}
+
+// storyDocument returns the JSON document of a story with its ID, review
+// status, type, tags and comment count added, as the story endpoints
+// return it.
+func storyDocument(story models.Story) map[string]interface{} {
+	document := story.Content
+	if document == nil {
+		document = map[string]interface{}{}
+	}
+	document["storyId"] = story.ID
+	document["storyStatus"] = story.Status
+	document["storyType"] = story.Type
+	document["storyTags"] = tagNames(story.Tags)
+	document["storyComments"] = story.Comments
+	return document
+}
@@ -0,0 +1,109 @@
+package controllers_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"blog_project.com/controllers"
+)
+
+// moderationPath returns the path of a moderation action on a story.
+func moderationPath(id int, action string) string {
+	return "/api/moderation/stories/" + strconv.Itoa(id) + "/" + action
+}
+
+// publicTitles returns the titles of the stories in the public feed.
+func (s *testServer) publicTitles() []string {
+	s.t.Helper()
+	var feed struct {
+		Stories []struct {
+			Story map[string]interface{} `json:"story"`
+		} `json:"stories"`
+	}
+	s.expect(http.StatusOK, "GET", "/api/public/stories", "", nil).decode(s.t, &feed)
+	var titles []string
+	for _, story := range feed.Stories {
+		title, _ := story.Story["title"].(string)
+		titles = append(titles, title)
+	}
+	return titles
+}
+
+func TestModerationPublishesReviewedStories(t *testing.T) {
+	forEachBackend(t, controllers.Options{}, func(t *testing.T, s *testServer) {
+		s.createUser("fox@example.com", "user")
+		s.createUser("walter@example.com", "editor")
+		author, editor := s.login("fox@example.com"), s.login("walter@example.com")
+
+		story := s.addStory(author, "Abduction")
+		path := storyPath(story.ID)
+		approve, reject := moderationPath(story.ID, "approve"), moderationPath(story.ID, "reject")
+
+		if code, resp := s.do("POST", approve, editor, nil); code != http.StatusConflict {
+			t.Errorf("approving a draft = %d %q, want 409", code, resp.Message)
+		}
+		s.expect(http.StatusOK, "POST", path+"/submit", author, nil)
+
+		if code, resp := s.do("POST", approve, author, nil); code != http.StatusForbidden {
+			t.Errorf("approving without the permission = %d %q, want 403", code, resp.Message)
+		}
+		if code, resp := s.do("GET", "/api/moderation/stories?status=pending_review", author, nil); code != http.StatusForbidden {
+			t.Errorf("listing the queue without the permission = %d %q, want 403", code, resp.Message)
+		}
+		if titles := s.publicTitles(); len(titles) != 0 {
+			t.Errorf("public feed before approval = %q, want none", titles)
+		}
+
+		var got storyDocument
+		s.expect(http.StatusOK, "POST", approve, editor, nil).decode(t, &got)
+		if got.Status != "published" {
+			t.Errorf("approved story = %+v", got)
+		}
+		if titles := s.publicTitles(); len(titles) != 1 || titles[0] != "Abduction" {
+			t.Errorf("public feed after approval = %q, want the approved story", titles)
+		}
+
+		// Edits by the author go back to review and leave the feed.
+		s.expect(http.StatusOK, "PUT", path, author, map[string]interface{}{
+			"story": map[string]interface{}{"title": "Abduction, revised", "body": "x"},
+		}).decode(t, &got)
+		if got.Status != "pending_review" {
+			t.Errorf("story edited after publication = %+v, want it pending review", got)
+		}
+		if titles := s.publicTitles(); len(titles) != 0 {
+			t.Errorf("public feed after the edit = %q, want none", titles)
+		}
+
+		if code, resp := s.do("POST", reject, editor, map[string]string{"reason": " "}); code != http.StatusBadRequest {
+			t.Errorf("rejecting without a reason = %d %q, want 400", code, resp.Message)
+		}
+		s.expect(http.StatusOK, "POST", reject, editor, map[string]string{"reason": "Needs sources"}).decode(t, &got)
+		if got.Status != "rejected" {
+			t.Errorf("rejected story = %+v", got)
+		}
+
+		var history []struct {
+			From   string `json:"from"`
+			To     string `json:"to"`
+			Reason string `json:"reason"`
+		}
+		s.expect(http.StatusOK, "GET", path+"/history", author, nil).decode(t, &history)
+		if len(history) != 4 || history[3].To != "rejected" || history[3].Reason != "Needs sources" {
+			t.Errorf("history = %+v, want submit, approve, edit and reject", history)
+		}
+	})
+}
+
+func TestModeratorsCannotReviewTheirOwnStories(t *testing.T) {
+	forEachBackend(t, controllers.Options{}, func(t *testing.T, s *testServer) {
+		s.createUser("walter@example.com", "editor")
+		editor := s.login("walter@example.com")
+
+		story := s.addStory(editor, "Memo")
+		s.expect(http.StatusOK, "POST", storyPath(story.ID)+"/submit", editor, nil)
+		if code, resp := s.do("POST", moderationPath(story.ID, "approve"), editor, nil); code != http.StatusForbidden {
+			t.Errorf("approving one's own story = %d %q, want 403", code, resp.Message)
+		}
+	})
+}
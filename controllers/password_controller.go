@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"blog_project.com/mail"
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/utils"
+)
+
+// Bounds on the length of a password that is changed or reset. bcrypt
+// only looks at the first 72 bytes.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// ChangePassword sets a new password for the authenticated user after
+// checking the current one.
+//
+// Every session of the user, including the one making the request, is
+// ended, so the client has to log in again with the new password.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.CurrentPassword == "" {
+		respondWithError(w, http.StatusBadRequest, "Current password is mandatory")
+		return
+	}
+	if message := checkNewPassword(req.NewPassword); message != "" {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	user, ok := h.loadCurrentUser(w, r)
+	if !ok {
+		return
+	}
+	if utils.CheckPasswordHash(req.CurrentPassword, user.Password) != nil {
+		respondWithError(w, http.StatusForbidden, "Current password is incorrect")
+		return
+	}
+
+	if err := h.setPassword(r.Context(), user.ID, req.NewPassword); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Password changed successfully, please log in again",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// ForgotPassword mails a password reset link to the given address.
+//
+// The response is the same whether or not the address belongs to an
+// account, so the endpoint cannot be used to find registered emails.
+// Mail failures are only logged, for the same reason.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		respondWithError(w, http.StatusBadRequest, "Email is mandatory")
+		return
+	}
+
+	ctx := r.Context()
+	user, err := h.users.FindByEmail(ctx, strings.TrimSpace(req.Email))
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Failed to request password reset, please try again")
+		return
+	case user.DeletedAt != nil:
+		// Accounts waiting to be purged are restored by logging in, not
+		// by a reset.
+	default:
+		if err := h.sendPasswordReset(ctx, user); err != nil {
+			log.Printf("Failed to send password reset to user %d: %v", user.ID, err)
+		}
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "If the email is registered, a password reset link has been sent to it",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// ResetPassword sets a new password using a token from a password reset
+// mail.
+//
+// Tokens can be used once. A successful reset invalidates every other
+// outstanding reset token of the user and ends all of their sessions.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		respondWithError(w, http.StatusBadRequest, "Reset token is mandatory")
+		return
+	}
+	if message := checkNewPassword(req.NewPassword); message != "" {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	ctx := r.Context()
+	token, err := h.resets.FindByHash(ctx, utils.HashToken(req.Token))
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired reset token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	now := time.Now().UTC()
+	if token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired reset token")
+		return
+	}
+	// Claim the token atomically so it cannot be used twice concurrently.
+	claimed, err := h.resets.MarkUsed(ctx, token.ID, now)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+	if !claimed {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired reset token")
+		return
+	}
+
+	if err := h.setPassword(ctx, token.UserID, req.NewPassword); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+	if err := h.resets.InvalidateUser(ctx, token.UserID, now); err != nil {
+		log.Printf("Failed to invalidate password reset tokens of user %d: %v", token.UserID, err)
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Password reset successfully, please log in with the new password",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// setPassword stores a new password for a user and ends every session
+// they have.
+func (h *Handler) setPassword(ctx context.Context, userID int, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := h.users.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return err
+	}
+	return h.revokeAllSessions(ctx, userID)
+}
+
+// sendPasswordReset creates a reset token for user and mails them a link
+// containing it. Only the hash of the token is stored.
+func (h *Handler) sendPasswordReset(ctx context.Context, user models.RegisterUserModel) error {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	err = h.resets.Create(ctx, models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(h.opts.PasswordResetTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := withQuery(h.opts.PasswordResetURL, "token", token)
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset your password. Open the link below to choose a new one:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s and can only be used once. If you did not ask for a reset, you can ignore this mail.\n",
+			user.FullName, link, h.opts.PasswordResetTTL),
+	})
+}
+
+// checkNewPassword returns a validation message for an unacceptable new
+// password, or "" when it is fine.
+func checkNewPassword(password string) string {
+	if password == "" {
+		return "New password is mandatory"
+	}
+	if len(password) < minPasswordLength {
+		return fmt.Sprintf("New password must be at least %d characters", minPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Sprintf("New password must be at most %d bytes", maxPasswordLength)
+	}
+	return ""
+}
+
+// withQuery returns rawURL with the query parameter name set to value.
+func withQuery(rawURL, name, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set(name, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
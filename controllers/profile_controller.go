@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/storage"
+	"blog_project.com/utils"
+)
+
+// accountPurgeInterval is how often accounts whose deletion grace period
+// has ended are looked for and purged.
+const accountPurgeInterval = time.Hour
+
+// UpdateProfile changes the full name and/or email of the authenticated
+// user.
+//
+// Only the fields present in the body are changed. Changing the email
+// requires the current password, so a stolen access token alone cannot
+// take over the account, and marks it unverified until the link mailed
+// to the new address is followed.
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.FullName == nil && req.Email == nil {
+		respondWithError(w, http.StatusBadRequest, "Nothing to update, send full_name and/or email")
+		return
+	}
+
+	user, ok := h.loadCurrentUser(w, r)
+	if !ok {
+		return
+	}
+
+	fullName, email := user.FullName, user.Email
+	if req.FullName != nil {
+		fullName = strings.TrimSpace(*req.FullName)
+		if fullName == "" {
+			respondWithError(w, http.StatusBadRequest, "Full name is mandatory")
+			return
+		}
+	}
+	if req.Email != nil {
+		email = strings.TrimSpace(*req.Email)
+		if !validEmail(email) {
+			respondWithError(w, http.StatusBadRequest, "Email is invalid")
+			return
+		}
+		if !strings.EqualFold(email, user.Email) {
+			if utils.CheckPasswordHash(req.CurrentPassword, user.Password) != nil {
+				respondWithError(w, http.StatusForbidden, "Current password is required to change the email")
+				return
+			}
+		}
+	}
+
+	err := h.users.UpdateProfile(r.Context(), user.ID, fullName, email)
+	if errors.Is(err, repositories.ErrDuplicateEmail) {
+		respondWithError(w, http.StatusConflict, "Email ID already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	emailChanged := !strings.EqualFold(email, user.Email)
+	user.FullName, user.Email = fullName, email
+	if emailChanged {
+		// The new address has never been mailed, so the resend limit
+		// does not apply
+		user.EmailVerifiedAt = nil
+		if err := h.sendVerification(r.Context(), user, false); err != nil {
+			log.Printf("Failed to send verification mail to user %d: %v", user.ID, err)
+		}
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Profile updated successfully",
+		Data:    h.profileModel(user),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// UpdateProfilePicture replaces the avatar of the authenticated user
+// with the picture uploaded in the profile_pic field and deletes the old
+// one.
+func (h *Handler) UpdateProfilePicture(w http.ResponseWriter, r *http.Request) {
+	if err := h.uploads.ParseForm(w, r); err != nil {
+		h.respondWithUploadError(w, err)
+		return
+	}
+
+	user, ok := h.loadCurrentUser(w, r)
+	if !ok {
+		return
+	}
+
+	key, err := h.saveProfilePicture(r.Context(), w, r, "profile_pic")
+	if errors.Is(err, storage.ErrMissingFile) {
+		respondWithError(w, http.StatusBadRequest, "Profile picture is mandatory")
+		return
+	}
+	if err != nil {
+		h.respondWithUploadError(w, err)
+		return
+	}
+
+	if err := h.users.UpdateProfilePic(r.Context(), user.ID, key); err != nil {
+		h.deleteProfilePicture(r.Context(), key)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update profile picture")
+		return
+	}
+	h.deleteProfilePicture(r.Context(), user.ProfilePic)
+
+	user.ProfilePic = key
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Profile picture updated successfully",
+		Data:    h.profileModel(user),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// DeleteAccount deletes the authenticated user after checking their
+// password.
+//
+// Every session is ended straight away. With a deletion grace period
+// configured the account is only scheduled for deletion, and logging in
+// again before the period ends cancels it; otherwise the user, their
+// stories and their files are purged immediately.
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	var req models.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "Password is mandatory")
+		return
+	}
+
+	user, ok := h.loadCurrentUser(w, r)
+	if !ok {
+		return
+	}
+	if utils.CheckPasswordHash(req.Password, user.Password) != nil {
+		respondWithError(w, http.StatusForbidden, "Password is incorrect")
+		return
+	}
+
+	ctx := r.Context()
+	if h.opts.DeletionGracePeriod <= 0 {
+		if err := h.purgeAccount(ctx, user); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+			return
+		}
+		successResponse := models.Response{
+			Status:  true,
+			Message: "Account deleted successfully",
+			Data:    struct{}{},
+		}
+		respondWithJSON(w, http.StatusOK, successResponse)
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := h.users.SetDeletedAt(ctx, user.ID, &now); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+	if err := h.revokeAllSessions(ctx, user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Account scheduled for deletion, log in again before purge_at to cancel",
+		Data:    models.AccountDeletionModel{PurgeAt: now.Add(h.opts.DeletionGracePeriod)},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// purgeAccount removes a user for good: sessions, stories, the user row
+// and the stored profile picture.
+func (h *Handler) purgeAccount(ctx context.Context, user models.RegisterUserModel) error {
+	if err := h.revokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := h.stories.DeleteByUser(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := h.comments.DeleteByUser(ctx, user.ID, time.Now().UTC()); err != nil {
+		return err
+	}
+	if err := h.users.Delete(ctx, user.ID); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return err
+	}
+	h.deleteProfilePicture(ctx, user.ProfilePic)
+	return nil
+}
+
+// purgeDeletedAccounts purges the accounts whose grace period has ended,
+// every interval until ctx is cancelled.
+func (h *Handler) purgeDeletedAccounts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			users, err := h.users.ListDeletedBefore(ctx, time.Now().UTC().Add(-h.opts.DeletionGracePeriod))
+			if err != nil {
+				log.Println("Failed to list deleted accounts:", err)
+				continue
+			}
+			for _, user := range users {
+				if err := h.purgeAccount(ctx, user); err != nil {
+					log.Printf("Failed to purge account %d: %v", user.ID, err)
+				}
+			}
+		}
+	}
+}
+
+// loadCurrentUser reads the authenticated user from the repository. It
+// writes a 404 or 500 error response and returns false when that fails.
+func (h *Handler) loadCurrentUser(w http.ResponseWriter, r *http.Request) (models.RegisterUserModel, bool) {
+	user, err := h.users.FindByID(r.Context(), currentPrincipal(r).UserID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return user, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load user")
+		return user, false
+	}
+	return user, true
+}
+
+// profileModel builds the profile returned to the owner of an account.
+func (h *Handler) profileModel(user models.RegisterUserModel) models.GetUserProfileModel {
+	return models.GetUserProfileModel{
+		FullName:           user.FullName,
+		Email:              user.Email,
+		ProfilePic:         h.profilePicURL(user.ProfilePic),
+		ProfilePicVariants: h.profilePicVariants(user.ProfilePic),
+		EmailVerified:      user.EmailVerifiedAt != nil,
+		Role:               user.Role,
+	}
+}
+
+// validEmail reports whether email is a bare email address.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
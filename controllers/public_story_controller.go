@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"github.com/gorilla/mux"
+)
+
+// Page sizes of GET /api/public/stories.
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+// publicFeedMaxAge is how long clients and shared caches may reuse a page
+// of the public feed.
+const publicFeedMaxAge = time.Minute
+
+// ListPublicStories returns a page of the published stories, with the
+// name and avatar of their authors. It needs no authentication.
+//
+// The query parameters are:
+//
+//	author  only stories of the user with this ID
+//	tag     only stories tagged with this tag, whatever its case
+//	sort    newest (default) or most_liked
+//	limit   stories per page, 20 by default
+//	cursor  the next_cursor of the previous page
+//
+// Responses carry an ETag and may be cached publicly for a minute.
+func (h *Handler) ListPublicStories(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := models.StoryFeedQuery{Tag: normalizeTagName(query.Get("tag")), Sort: query.Get("sort")}
+	if q.Sort == "" {
+		q.Sort = models.StorySortNewest
+	}
+	if q.Sort != models.StorySortNewest && q.Sort != models.StorySortMostLiked {
+		respondWithError(w, http.StatusBadRequest, "sort must be newest or most_liked")
+		return
+	}
+	authorID, ok := queryInt(query.Get("author"), 0)
+	if !ok || authorID < 0 {
+		respondWithError(w, http.StatusBadRequest, "author must be a user ID")
+		return
+	}
+	q.AuthorID = authorID
+	limit, ok := queryInt(query.Get("limit"), defaultFeedLimit)
+	if !ok || limit < 1 || limit > maxFeedLimit {
+		respondWithError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxFeedLimit))
+		return
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		after, err := decodeFeedCursor(raw, q.Sort)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+		q.After = &after
+	}
+
+	// One extra story tells whether there is a next page
+	q.Limit = limit + 1
+	stories, err := h.stories.ListPublished(r.Context(), q)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve stories")
+		return
+	}
+
+	feed := models.PublicStoryFeedModel{Stories: make([]models.PublicStoryModel, 0, limit)}
+	if len(stories) > limit {
+		stories = stories[:limit]
+		last := stories[limit-1]
+		feed.NextCursor = encodeFeedCursor(q.Sort, models.StoryFeedCursor{Likes: last.Likes, CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	for _, story := range stories {
+		feed.Stories = append(feed.Stories, models.PublicStoryModel{
+			ID:    story.ID,
+			Type:  story.Type,
+			Story: story.Content,
+			Tags:  tagNames(story.Tags),
+			Author: models.StoryAuthorModel{
+				ID:                 story.UserID,
+				FullName:           story.AuthorName,
+				ProfilePic:         h.profilePicURL(story.AuthorProfilePic),
+				ProfilePicVariants: h.profilePicVariants(story.AuthorProfilePic),
+			},
+			Likes:     story.Likes,
+			Comments:  story.Comments,
+			CreatedAt: story.CreatedAt,
+		})
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Stories retrieved successfully",
+		Data:    feed,
+	}
+	respondWithCachedJSON(w, r, successResponse, publicFeedMaxAge)
+}
+
+// LikeStory records that the authenticated user likes a published story.
+func (h *Handler) LikeStory(w http.ResponseWriter, r *http.Request) {
+	h.setStoryLike(w, r, true)
+}
+
+// UnlikeStory removes the like of the authenticated user from a published
+// story.
+func (h *Handler) UnlikeStory(w http.ResponseWriter, r *http.Request) {
+	h.setStoryLike(w, r, false)
+}
+
+// setStoryLike implements LikeStory and UnlikeStory.
+func (h *Handler) setStoryLike(w http.ResponseWriter, r *http.Request, like bool) {
+	story, ok := h.loadPublishedStory(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	userID := currentPrincipal(r).UserID
+	var err error
+	message := "Story liked"
+	if like {
+		err = h.stories.Like(ctx, story.ID, userID, time.Now().UTC())
+	} else {
+		err = h.stories.Unlike(ctx, story.ID, userID)
+		message = "Story unliked"
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update like")
+		return
+	}
+	likes, err := h.stories.CountLikes(ctx, story.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update like")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: message,
+		Data:    models.StoryLikesModel{Likes: likes},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// loadPublishedStory reads the story named by the {id} route variable.
+// Stories that are not published are answered with a 404, as if they did
+// not exist.
+//
+// It writes a 400, 404 or 500 error response and returns false when the
+// story cannot be used.
+func (h *Handler) loadPublishedStory(w http.ResponseWriter, r *http.Request) (models.Story, bool) {
+	storyID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || storyID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid story ID")
+		return models.Story{}, false
+	}
+
+	story, err := h.stories.FindByID(r.Context(), storyID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && story.Status != models.StoryPublished) {
+		respondWithError(w, http.StatusNotFound, "Story not found")
+		return models.Story{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve story")
+		return models.Story{}, false
+	}
+	return story, true
+}
+
+// feedCursor is the JSON content of an opaque feed cursor. The sort order
+// is included so a cursor cannot be reused with another order.
+type feedCursor struct {
+	Sort      string `json:"s"`
+	Likes     int    `json:"l,omitempty"`
+	CreatedAt int64  `json:"t"`
+	ID        int    `json:"i"`
+}
+
+// encodeFeedCursor returns the opaque cursor continuing a feed in the
+// given order after position.
+func encodeFeedCursor(sort string, position models.StoryFeedCursor) string {
+	data, _ := json.Marshal(feedCursor{
+		Sort:      sort,
+		Likes:     position.Likes,
+		CreatedAt: position.CreatedAt.Unix(),
+		ID:        position.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeFeedCursor parses a cursor made by encodeFeedCursor for the same
+// sort order.
+func decodeFeedCursor(raw, sort string) (models.StoryFeedCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return models.StoryFeedCursor{}, err
+	}
+	var cursor feedCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return models.StoryFeedCursor{}, err
+	}
+	if cursor.Sort != sort || cursor.ID <= 0 || cursor.Likes < 0 {
+		return models.StoryFeedCursor{}, errors.New("cursor does not match the query")
+	}
+	return models.StoryFeedCursor{
+		Likes:     cursor.Likes,
+		CreatedAt: time.Unix(cursor.CreatedAt, 0).UTC(),
+		ID:        cursor.ID,
+	}, nil
+}
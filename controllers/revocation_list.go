@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/utils"
+)
+
+// revocationSyncInterval is how often the in-memory revocation cache is
+// reloaded from the database and expired entries are purged.
+const revocationSyncInterval = time.Minute
+
+// tokenRevocationList keeps revoked access tokens and disabled users in
+// memory so that checking a token on every authenticated request does not
+// cost a query.
+//
+// Revocations are written to the repositories first and mirrored into
+// the cache. The cache is periodically reloaded so that revocations
+// made by other instances are picked up, and entries whose tokens would
+// have expired anyway are dropped from both the cache and the database.
+// Reloads are merged into the cache, so a revocation written while one
+// is in progress is kept.
+type tokenRevocationList struct {
+	repo      repositories.TokenRepository
+	usersRepo repositories.UserRepository
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time // jti -> expiry of the revoked token
+	users    map[int]time.Time    // user ID -> tokens issued before this time are revoked
+	disabled map[int]bool         // IDs of disabled users
+
+	// generation counts the calls to setDisabled; disabledChanged holds
+	// the generation each user was last disabled or enabled in, until a
+	// reload that started afterwards has read it back.
+	generation      uint64
+	disabledChanged map[int]uint64
+}
+
+func newTokenRevocationList(repo repositories.TokenRepository, usersRepo repositories.UserRepository) *tokenRevocationList {
+	return &tokenRevocationList{
+		repo:      repo,
+		usersRepo: usersRepo,
+		tokens:    map[string]time.Time{},
+		users:     map[int]time.Time{},
+		disabled:  map[int]bool{},
+
+		disabledChanged: map[int]uint64{},
+	}
+}
+
+// IsRevoked implements utils.TokenRevocationList.
+func (l *tokenRevocationList) IsRevoked(principal models.Principal) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if principal.TokenID != "" {
+		if _, ok := l.tokens[principal.TokenID]; ok {
+			return true
+		}
+	}
+	if cutoff, ok := l.users[principal.UserID]; ok && principal.IssuedAt.Before(cutoff) {
+		return true
+	}
+	return false
+}
+
+// IsDisabled implements utils.TokenRevocationList.
+func (l *tokenRevocationList) IsDisabled(userID int) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.disabled[userID]
+}
+
+// setDisabled disables a user, or enables them again when at is nil.
+func (l *tokenRevocationList) setDisabled(ctx context.Context, userID int, at *time.Time) error {
+	if err := l.usersRepo.SetDisabledAt(ctx, userID, at); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	if at != nil {
+		l.disabled[userID] = true
+	} else {
+		delete(l.disabled, userID)
+	}
+	l.generation++
+	l.disabledChanged[userID] = l.generation
+	l.mu.Unlock()
+	return nil
+}
+
+// revokeToken revokes a single access token until it expires.
+func (l *tokenRevocationList) revokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	if err := l.repo.RevokeAccessToken(ctx, tokenID, userID, expiresAt); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.tokens[tokenID] = expiresAt
+	l.mu.Unlock()
+	return nil
+}
+
+// revokeUser revokes every access token issued to a user so far.
+func (l *tokenRevocationList) revokeUser(ctx context.Context, userID int) error {
+	now := utils.RevocationCutoff()
+	if err := l.repo.RevokeUserAccessTokens(ctx, userID, now, now.Add(utils.AccessTokenTTL)); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	if now.After(l.users[userID]) {
+		l.users[userID] = now
+	}
+	l.mu.Unlock()
+	return nil
+}
+
+// load purges expired revocations and merges the ones left in the
+// repositories into the cache.
+//
+// Writes made by this instance while the repositories are read win over
+// what was read: cached token and user revocations are kept until they
+// expire, the later of two user cut-offs is kept, and users disabled or
+// enabled after the reload started keep their cached state.
+func (l *tokenRevocationList) load(ctx context.Context) error {
+	l.mu.RLock()
+	generation := l.generation
+	l.mu.RUnlock()
+
+	now := time.Now().UTC()
+	if err := l.repo.PurgeExpiredRevocations(ctx, now); err != nil {
+		return err
+	}
+	revoked, err := l.repo.RevokedAccessTokens(ctx)
+	if err != nil {
+		return err
+	}
+	disabledIDs, err := l.usersRepo.DisabledIDs(ctx)
+	if err != nil {
+		return err
+	}
+	disabled := make(map[int]bool, len(disabledIDs))
+	for _, id := range disabledIDs {
+		disabled[id] = true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for tokenID, expiresAt := range l.tokens {
+		if _, ok := revoked.Tokens[tokenID]; !ok && expiresAt.After(now) {
+			revoked.Tokens[tokenID] = expiresAt
+		}
+	}
+	for userID, cutoff := range l.users {
+		if cutoff.After(revoked.Users[userID]) && cutoff.Add(utils.AccessTokenTTL).After(now) {
+			revoked.Users[userID] = cutoff
+		}
+	}
+	for userID, changed := range l.disabledChanged {
+		if changed <= generation {
+			delete(l.disabledChanged, userID)
+			continue
+		}
+		if l.disabled[userID] {
+			disabled[userID] = true
+		} else {
+			delete(disabled, userID)
+		}
+	}
+	l.tokens = revoked.Tokens
+	l.users = revoked.Users
+	l.disabled = disabled
+	return nil
+}
+
+// sync reloads the cache every interval until ctx is cancelled.
+func (l *tokenRevocationList) sync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.load(ctx); err != nil {
+				log.Println("Failed to reload token revocation list:", err)
+			}
+		}
+	}
+}
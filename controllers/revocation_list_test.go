@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+)
+
+// racingTokens calls during after reading the revocations, the way a
+// write committed by this instance while a reload is in progress would
+// land.
+type racingTokens struct {
+	repositories.TokenRepository
+	during func()
+}
+
+func (r racingTokens) RevokedAccessTokens(ctx context.Context) (models.RevokedAccessTokens, error) {
+	revoked, err := r.TokenRepository.RevokedAccessTokens(ctx)
+	if r.during != nil {
+		r.during()
+	}
+	return revoked, err
+}
+
+func TestRevocationListLoadKeepsConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemory()
+	tokens := &racingTokens{TokenRepository: repos.Tokens}
+	list := newTokenRevocationList(tokens, repos.Users)
+
+	enabledID, err := repos.Users.Create(ctx, models.RegisterUserModel{Email: "enabled@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	disabledID, err := repos.Users.Create(ctx, models.RegisterUserModel{Email: "disabled@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	if err := list.setDisabled(ctx, enabledID, &now); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.load(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens.during = func() {
+		if err := list.revokeToken(ctx, "jti", 7, now.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if err := list.revokeUser(ctx, 8); err != nil {
+			t.Fatal(err)
+		}
+		if err := list.setDisabled(ctx, enabledID, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := list.setDisabled(ctx, disabledID, &now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := list.load(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !list.IsRevoked(models.Principal{UserID: 7, TokenID: "jti"}) {
+		t.Error("token revoked during the reload is no longer revoked")
+	}
+	if !list.IsRevoked(models.Principal{UserID: 8, IssuedAt: now}) {
+		t.Error("user revoked during the reload is no longer revoked")
+	}
+	if list.IsDisabled(enabledID) {
+		t.Error("user enabled during the reload is disabled again")
+	}
+	if !list.IsDisabled(disabledID) {
+		t.Error("user disabled during the reload is no longer disabled")
+	}
+
+	// Once a reload has read them back, the repositories decide again.
+	tokens.during = nil
+	if err := repos.Users.SetDisabledAt(ctx, disabledID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if list.IsDisabled(disabledID) {
+		t.Error("user enabled by another instance is still disabled")
+	}
+}
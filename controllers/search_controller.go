@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"blog_project.com/models"
+	"blog_project.com/search"
+)
+
+// Limits of GET /api/search.
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	maxSearchQuery     = 200
+)
+
+// Search finds the published stories matching the q query parameter and,
+// for callers allowed to manage accounts, the users whose name or email
+// matches it. Results are ranked best first, at most limit (10 by
+// default) of each, with a highlighted snippet showing why they matched.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if len(search.Tokenize(q)) == 0 {
+		respondWithError(w, http.StatusBadRequest, "q must contain a word of at least three characters")
+		return
+	}
+	if utf8.RuneCountInString(q) > maxSearchQuery {
+		respondWithError(w, http.StatusBadRequest, "q must be at most "+strconv.Itoa(maxSearchQuery)+" characters long")
+		return
+	}
+	limit, ok := queryInt(query.Get("limit"), defaultSearchLimit)
+	if !ok || limit < 1 || limit > maxSearchLimit {
+		respondWithError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxSearchLimit))
+		return
+	}
+
+	ctx := r.Context()
+	storyHits, err := h.search.SearchStories(ctx, q, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to search")
+		return
+	}
+	results := models.SearchResultsModel{Query: q, Stories: make([]models.StorySearchResultModel, 0, len(storyHits))}
+	for _, hit := range storyHits {
+		story := hit.Story
+		results.Stories = append(results.Stories, models.StorySearchResultModel{
+			ID:   story.ID,
+			Type: story.Type,
+			Author: models.StoryAuthorModel{
+				ID:                 story.UserID,
+				FullName:           story.AuthorName,
+				ProfilePic:         h.profilePicURL(story.AuthorProfilePic),
+				ProfilePicVariants: h.profilePicVariants(story.AuthorProfilePic),
+			},
+			Snippet:   search.Snippet(search.DocumentText(story.Content), q),
+			Score:     hit.Score,
+			Likes:     story.Likes,
+			CreatedAt: story.CreatedAt,
+		})
+	}
+
+	if currentPrincipal(r).Can(models.PermissionManageUsers) {
+		userHits, err := h.search.SearchUsers(ctx, q, limit)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to search")
+			return
+		}
+		results.Users = make([]models.UserSearchResultModel, 0, len(userHits))
+		for _, hit := range userHits {
+			user := hit.User
+			results.Users = append(results.Users, models.UserSearchResultModel{
+				ID:         user.ID,
+				FullName:   user.FullName,
+				Email:      user.Email,
+				ProfilePic: h.profilePicURL(user.ProfilePic),
+				Role:       user.Role,
+				Snippet:    search.Snippet(user.FullName+" "+user.Email, q),
+				Score:      hit.Score,
+			})
+		}
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Search completed successfully",
+		Data:    results,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/utils"
+	"github.com/gorilla/mux"
+)
+
+// ListStoryRevisions returns every revision of a story, oldest first,
+// without their documents. Stories written before revisions existed only
+// have revisions from their first change on.
+func (h *Handler) ListStoryRevisions(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.stories.ListRevisions(r.Context(), story.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve story revisions")
+		return
+	}
+	if revisions == nil {
+		revisions = []models.StoryRevision{}
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story revisions retrieved successfully",
+		Data:    revisions,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// GetStoryRevision returns a revision of a story with its document.
+func (h *Handler) GetStoryRevision(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+	rev, ok := h.loadRevision(w, r, story.ID, mux.Vars(r)["revision"])
+	if !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story revision retrieved successfully",
+		Data:    rev,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// DiffStoryRevisions returns the structural differences between two
+// revisions of a story, named by the from and to query parameters. to
+// defaults to the latest revision and from to the one before to.
+func (h *Handler) DiffStoryRevisions(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	toParam, fromParam := query.Get("to"), query.Get("from")
+	if toParam == "" {
+		revisions, err := h.stories.ListRevisions(r.Context(), story.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve story revisions")
+			return
+		}
+		if len(revisions) == 0 {
+			respondWithError(w, http.StatusNotFound, "Revision not found")
+			return
+		}
+		toParam = strconv.Itoa(revisions[len(revisions)-1].Revision)
+	}
+	to, ok := h.loadRevision(w, r, story.ID, toParam)
+	if !ok {
+		return
+	}
+	if fromParam == "" {
+		if to.Revision == 1 {
+			respondWithError(w, http.StatusBadRequest, "Revision 1 has no previous revision to compare with")
+			return
+		}
+		fromParam = strconv.Itoa(to.Revision - 1)
+	}
+	from, ok := h.loadRevision(w, r, story.ID, fromParam)
+	if !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story revisions compared successfully",
+		Data: models.StoryDiffModel{
+			From:     from.Revision,
+			To:       to.Revision,
+			FromType: from.Type,
+			ToType:   to.Type,
+			Changes:  utils.DiffJSON(from.Content, to.Content),
+		},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// RestoreStoryRevision copies an old revision of a story into a new one,
+// type included. The old document must still match its type. A published
+// story restored by its author goes back to review.
+func (h *Handler) RestoreStoryRevision(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+	rev, ok := h.loadRevision(w, r, story.ID, mux.Vars(r)["revision"])
+	if !ok {
+		return
+	}
+	if !h.validateStory(w, rev.Type, rev.Content) {
+		return
+	}
+
+	story, restored, ok := h.saveStory(w, r, story, models.StoryRevision{
+		Type:         rev.Type,
+		Content:      rev.Content,
+		RestoredFrom: rev.Revision,
+	}, nil)
+	if !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Revision " + strconv.Itoa(rev.Revision) + " restored as revision " + strconv.Itoa(restored.Revision),
+		Data:    storyDocument(story),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// loadRevision reads the revision numbered value of a story. It writes a
+// 400, 404 or 500 error response and returns false when that fails.
+func (h *Handler) loadRevision(w http.ResponseWriter, r *http.Request, storyID int, value string) (models.StoryRevision, bool) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid revision number")
+		return models.StoryRevision{}, false
+	}
+
+	rev, err := h.stories.FindRevision(r.Context(), storyID, number)
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Revision not found")
+		return rev, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve story revision")
+		return rev, false
+	}
+	return rev, true
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"blog_project.com/models"
+)
+
+// ListStoryTypes returns the types new stories can be written in, with
+// the JSON Schema of each so clients can check stories before sending
+// them.
+func (h *Handler) ListStoryTypes(w http.ResponseWriter, r *http.Request) {
+	types := h.types.Types()
+	list := make([]models.StoryTypeModel, 0, len(types))
+	for _, t := range types {
+		list = append(list, t.Model())
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story types retrieved successfully",
+		Data:    list,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// requireStoryType makes sure new stories can be written in storyType.
+// It writes a 400 error response listing the valid types and returns
+// false otherwise.
+func (h *Handler) requireStoryType(w http.ResponseWriter, storyType string) bool {
+	if h.types.Creatable(storyType) {
+		return true
+	}
+	names := make([]string, 0)
+	for _, t := range h.types.Types() {
+		names = append(names, t.Name)
+	}
+	respondWithError(w, http.StatusBadRequest, "type must be one of "+strings.Join(names, ", "))
+	return false
+}
+
+// validateStory checks content against the schema of storyType. It writes
+// a 422 response listing the problems with each field and returns false
+// when the story does not match.
+func (h *Handler) validateStory(w http.ResponseWriter, storyType string, content map[string]interface{}) bool {
+	fieldErrors, err := h.types.Validate(storyType, content)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unknown story type "+storyType)
+		return false
+	}
+	if len(fieldErrors) == 0 {
+		return true
+	}
+
+	errorResponse := models.Response{
+		Status:  false,
+		Message: "Story does not match the " + storyType + " type",
+		Data:    models.StoryValidationModel{Type: storyType, Errors: fieldErrors},
+	}
+	respondWithJSON(w, http.StatusUnprocessableEntity, errorResponse)
+	return false
+}
+
+// storyContent returns a story document sent by a client without the
+// fields storyDocument adds, so documents read from the API can be sent
+// back as they are.
+func storyContent(document map[string]interface{}) map[string]interface{} {
+	if document == nil {
+		document = map[string]interface{}{}
+	}
+	delete(document, "storyId")
+	delete(document, "storyStatus")
+	delete(document, "storyType")
+	delete(document, "storyTags")
+	delete(document, "storyComments")
+	return document
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"github.com/gorilla/mux"
+)
+
+// tagListMaxAge is how long clients and shared caches may reuse the
+// public tag list.
+const tagListMaxAge = 5 * time.Minute
+
+// ListTags returns the tags of the published stories, with how many
+// published stories carry each, so the website can show its sections.
+// It needs no authentication.
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tags.List(r.Context(), true)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve tags")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Tags retrieved successfully",
+		Data:    append([]models.Tag{}, tags...),
+	}
+	respondWithCachedJSON(w, r, successResponse, tagListMaxAge)
+}
+
+// ListAllTags returns every tag, including those no story carries any
+// more, with how many stories of any status carry each.
+func (h *Handler) ListAllTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tags.List(r.Context(), false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve tags")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Tags retrieved successfully",
+		Data:    append([]models.Tag{}, tags...),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// RenameTag renames a tag on every story carrying it. A tag cannot take
+// the name of another one; merge them instead.
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	var req models.RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	name := normalizeTagName(req.Name)
+	if message := checkTagName(name); message != "" {
+		respondWithError(w, http.StatusBadRequest, "name "+message)
+		return
+	}
+
+	tag, ok := h.loadTag(w, r)
+	if !ok {
+		return
+	}
+
+	if name != tag.Name {
+		err := h.tags.Rename(r.Context(), tag.ID, name)
+		if errors.Is(err, repositories.ErrDuplicateTag) {
+			respondWithError(w, http.StatusConflict, "A tag named "+name+" already exists; merge the tags instead")
+			return
+		}
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to rename tag")
+			return
+		}
+		tag.Name = name
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Tag renamed successfully",
+		Data:    tag,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// MergeTag moves every story of a tag to another tag and deletes the
+// first one. The response is the tag the stories were merged into.
+func (h *Handler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	var req models.MergeTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Into <= 0 {
+		respondWithError(w, http.StatusBadRequest, "into must be a tag ID")
+		return
+	}
+
+	tag, ok := h.loadTag(w, r)
+	if !ok {
+		return
+	}
+	if req.Into == tag.ID {
+		respondWithError(w, http.StatusBadRequest, "A tag cannot be merged into itself")
+		return
+	}
+
+	ctx := r.Context()
+	err := h.tags.Merge(ctx, tag.ID, req.Into)
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to merge tags")
+		return
+	}
+	into, err := h.tags.FindByID(ctx, req.Into)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve tag")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Tag " + tag.Name + " merged into " + into.Name,
+		Data:    into,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// loadTag reads the tag named by the {id} route variable. It writes a
+// 400, 404 or 500 error response and returns false when that fails.
+func (h *Handler) loadTag(w http.ResponseWriter, r *http.Request) (models.Tag, bool) {
+	tagID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || tagID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag ID")
+		return models.Tag{}, false
+	}
+
+	tag, err := h.tags.FindByID(r.Context(), tagID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Tag not found")
+		return models.Tag{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve tag")
+		return models.Tag{}, false
+	}
+	return tag, true
+}
+
+// requireTags normalizes the tag names sent with a story, dropping those
+// repeated regardless of case. It writes a 400 error response and
+// returns false when a name is empty or too long, or when there are too
+// many of them.
+func requireTags(w http.ResponseWriter, names []string) ([]string, bool) {
+	tags := []string{}
+	seen := map[string]bool{}
+	for i, name := range names {
+		name = normalizeTagName(name)
+		if message := checkTagName(name); message != "" {
+			respondWithError(w, http.StatusBadRequest, "tags["+strconv.Itoa(i)+"] "+message)
+			return nil, false
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			tags = append(tags, name)
+		}
+	}
+	if len(tags) > models.MaxStoryTags {
+		respondWithError(w, http.StatusBadRequest, "A story can have at most "+strconv.Itoa(models.MaxStoryTags)+" tags")
+		return nil, false
+	}
+	return tags, true
+}
+
+// normalizeTagName trims a tag name and collapses the spaces within it.
+func normalizeTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// checkTagName returns what is wrong with a normalized tag name, or ""
+// when it is valid.
+func checkTagName(name string) string {
+	if name == "" {
+		return "must not be empty"
+	}
+	if utf8.RuneCountInString(name) > models.MaxTagNameLen {
+		return "must be at most " + strconv.Itoa(models.MaxTagNameLen) + " characters long"
+	}
+	return ""
+}
+
+// tagNames returns the tag names of a story as they are serialized, as
+// an empty list rather than null when it has none.
+func tagNames(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// hasTag reports whether tags contains name regardless of case.
+func hasTag(tags []string, name string) bool {
+	for _, tag := range tags {
+		if strings.EqualFold(tag, name) {
+			return true
+		}
+	}
+	return false
+}
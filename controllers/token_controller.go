@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/utils"
+)
+
+// issueSession creates an access token and a refresh token for a user.
+// The access token carries the user's current role.
+//
+// The refresh token starts a new token family unless familyID is given,
+// in which case it continues an existing family during rotation. The
+// family ID doubles as the session ID embedded in the access token so
+// that logging out can revoke both. Only the SHA-256 hash of the
+// refresh token is stored.
+func (h *Handler) issueSession(ctx context.Context, user models.RegisterUserModel, familyID string) (string, string, error) {
+	var err error
+	if familyID == "" {
+		familyID, err = utils.GenerateOpaqueToken()
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	accessToken, err := utils.GenerateToken(user.ID, user.Email, []string{user.Role}, familyID)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now().UTC()
+	err = h.tokens.CreateRefreshToken(ctx, models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: now.Add(utils.RefreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// RefreshToken exchanges a refresh token for a new access token.
+//
+// Every refresh token can be used exactly once: a successful call marks
+// it as used and returns a new refresh token from the same family. If a
+// token that was already used or revoked is presented again, the token
+// has most likely been stolen, so the whole family is revoked and the
+// user has to log in again.
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		respondWithError(w, http.StatusBadRequest, "Refresh token is mandatory")
+		return
+	}
+
+	ctx := r.Context()
+	token, err := h.tokens.FindRefreshToken(ctx, utils.HashToken(req.RefreshToken))
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid refresh token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to validate refresh token")
+		return
+	}
+
+	now := time.Now().UTC()
+	if token.Spent() {
+		h.revokeTokenFamily(ctx, token.FamilyID)
+		respondWithError(w, http.StatusUnauthorized, "Refresh token has already been used, please log in again")
+		return
+	}
+	if !token.ExpiresAt.After(now) {
+		respondWithError(w, http.StatusUnauthorized, "Refresh token has expired, please log in again")
+		return
+	}
+
+	// Claim the token atomically so two concurrent refreshes with the
+	// same token cannot both succeed.
+	claimed, err := h.tokens.MarkRefreshTokenUsed(ctx, token.ID, now)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to rotate refresh token")
+		return
+	}
+	if !claimed {
+		h.revokeTokenFamily(ctx, token.FamilyID)
+		respondWithError(w, http.StatusUnauthorized, "Refresh token has already been used, please log in again")
+		return
+	}
+
+	user, err := h.users.FindByID(ctx, token.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid refresh token")
+		return
+	}
+	if user.DisabledAt != nil {
+		respondWithError(w, http.StatusForbidden, "Your account has been disabled")
+		return
+	}
+	if user.EmailVerifiedAt == nil && !h.opts.AllowUnverifiedLogin {
+		respondWithError(w, http.StatusForbidden, "Please verify your email before logging in")
+		return
+	}
+
+	accessToken, refreshToken, err := h.issueSession(ctx, user, token.FamilyID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to generate token, please try again")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:       true,
+		Message:      "Token refreshed successfully",
+		Data:         struct{}{},
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// revokeTokenFamily revokes every refresh token descending from the same
+// login.
+func (h *Handler) revokeTokenFamily(ctx context.Context, familyID string) error {
+	return h.tokens.RevokeRefreshTokenFamily(ctx, familyID, time.Now().UTC())
+}
+
+// Logout ends the session the presented access token belongs to.
+//
+// The access token is added to the revocation list until it expires and
+// the refresh tokens of the same session are revoked, so neither can be
+// used again.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	principal := currentPrincipal(r)
+
+	if principal.TokenID != "" {
+		if err := h.revocations.revokeToken(r.Context(), principal.TokenID, principal.UserID, principal.ExpiresAt); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to log out, please try again")
+			return
+		}
+	}
+	if principal.SessionID != "" {
+		if err := h.revokeTokenFamily(r.Context(), principal.SessionID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to log out, please try again")
+			return
+		}
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Logged out successfully",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// LogoutAll ends every session of the authenticated user, on every
+// device.
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	principal := currentPrincipal(r)
+
+	if err := h.revokeAllSessions(r.Context(), principal.UserID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to log out, please try again")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Logged out from all sessions successfully",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// revokeAllSessions revokes every refresh token and every access token
+// issued to a user so far.
+func (h *Handler) revokeAllSessions(ctx context.Context, userID int) error {
+	if err := h.tokens.RevokeUserRefreshTokens(ctx, userID, time.Now().UTC()); err != nil {
+		return err
+	}
+	return h.revocations.revokeUser(ctx, userID)
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog_project.com/imaging"
+	"blog_project.com/storage"
+)
+
+// profilePicPrefix is the key prefix profile pictures are stored under.
+const profilePicPrefix = "profile-pics/"
+
+// saveProfilePicture stores the picture uploaded in field, without its
+// metadata, together with a square thumbnail for every size in
+// imaging.ThumbnailSizes and returns the key of the full-size picture.
+func (h *Handler) saveProfilePicture(ctx context.Context, w http.ResponseWriter, r *http.Request, field string) (string, error) {
+	data, _, err := h.uploads.Receive(w, r, field)
+	if err != nil {
+		return "", err
+	}
+
+	pic, err := imaging.Process(data, imaging.ThumbnailSizes)
+	if err != nil {
+		// The content sniffed as an image but does not decode as one.
+		return "", storage.ErrUnsupportedType
+	}
+
+	key, err := storage.NewKey(profilePicPrefix, pic.Ext)
+	if err != nil {
+		return "", err
+	}
+	if err := h.blobs.Put(ctx, key, bytes.NewReader(pic.Original), pic.ContentType); err != nil {
+		return "", err
+	}
+	for _, size := range imaging.ThumbnailSizes {
+		err := h.blobs.Put(ctx, imaging.VariantKey(key, size), bytes.NewReader(pic.Thumbnails[size]), pic.ContentType)
+		if err != nil {
+			h.deleteProfilePicture(ctx, key)
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// deleteProfilePicture removes a stored profile picture and its
+// thumbnails. Failures are only logged: an orphaned file is harmless.
+func (h *Handler) deleteProfilePicture(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	keys := []string{strings.TrimPrefix(key, "uploads/")}
+	if strings.HasPrefix(key, profilePicPrefix) {
+		for _, size := range imaging.ThumbnailSizes {
+			keys = append(keys, imaging.VariantKey(key, size))
+		}
+	}
+	for _, k := range keys {
+		if err := h.blobs.Delete(ctx, k); err != nil {
+			log.Printf("Failed to delete %s: %v", k, err)
+		}
+	}
+}
+
+// profilePicURL returns the download URL for a stored profile picture.
+//
+// Accounts created before uploads went through storage.Blob stored the
+// picture as "uploads/<key>"; the prefix is dropped so they resolve to
+// the same object.
+func (h *Handler) profilePicURL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return h.blobs.URL(strings.TrimPrefix(key, "uploads/"))
+}
+
+// profilePicVariants returns the thumbnail URLs of a stored profile
+// picture keyed by edge length. Pictures uploaded before thumbnails were
+// generated have none.
+func (h *Handler) profilePicVariants(key string) map[string]string {
+	if !strings.HasPrefix(key, profilePicPrefix) {
+		return nil
+	}
+	variants := make(map[string]string, len(imaging.ThumbnailSizes))
+	for _, size := range imaging.ThumbnailSizes {
+		variants[strconv.Itoa(size)] = h.blobs.URL(imaging.VariantKey(key, size))
+	}
+	return variants
+}
+
+// respondWithUploadError maps an error from storage.Uploader to an error
+// response.
+func (h *Handler) respondWithUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrTooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("File is too large, the limit is %d KB", h.uploads.MaxBytes()>>10))
+	case errors.Is(err, storage.ErrUnsupportedType):
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG, PNG and GIF images are allowed")
+	case errors.Is(err, storage.ErrMissingFile):
+		respondWithError(w, http.StatusBadRequest, "File is mandatory")
+	case errors.Is(err, storage.ErrMalformedForm):
+		respondWithError(w, http.StatusBadRequest, "Invalid multipart form")
+	default:
+		log.Println("Failed to store upload:", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to store file")
+	}
+}
@@ -1,38 +1,26 @@
 package controllers
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"blog_project.com/models"
+	"blog_project.com/repositories"
 	"blog_project.com/utils"
+	"github.com/gorilla/mux"
 )
 
 // AddStory handles adding a single story for a user.
-func AddStory(w http.ResponseWriter, r *http.Request) {
-	var userID int
-	var err error
-
-	// Extract and validate token from the header
-	tokenString := r.Header.Get("Authorization")
-	if tokenString == "" {
-		respondWithError(w, http.StatusUnauthorized, "Missing authorization token")
-		return
-	}
-
-	// Remove "Bearer " prefix and trim spaces
-	tokenString = strings.TrimSpace(strings.TrimPrefix(tokenString, "Bearer "))
-	if tokenString == "" {
-		respondWithError(w, http.StatusUnauthorized, "Invalid token format")
-		return
-	}
-
-	// Parse the user ID from the token
-	userID, err = utils.ParseToken(tokenString)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+//
+// The story must match the schema of its type; a 422 response lists the
+// problems with each field otherwise. Tags that do not exist yet are
+// created.
+func (h *Handler) AddStory(w http.ResponseWriter, r *http.Request) {
+	userID := currentPrincipal(r).UserID
+	if !h.requireVerifiedEmail(w, r) {
 		return
 	}
 
@@ -43,16 +31,20 @@ func AddStory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Marshal the JSON story to store it as a JSON column
-	storyJSON, err := json.Marshal(req.Story)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to encode story")
+	if !h.requireStoryType(w, req.Type) {
+		return
+	}
+	content := storyContent(req.Story)
+	if !h.validateStory(w, req.Type, content) {
+		return
+	}
+	tags, ok := requireTags(w, req.Tags)
+	if !ok {
 		return
 	}
 
 	// Insert the new story into the database with userID
-	_, err = db.Exec("INSERT INTO usersStory (stories, userId) VALUES (?, ?)", storyJSON, userID)
-	if err != nil {
+	if _, err := h.stories.Create(r.Context(), userID, req.Type, content, tags); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to store story")
 		return
 	}
@@ -65,58 +57,27 @@ func AddStory(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, successResponse)
 }
 
-func GetStory(w http.ResponseWriter, r *http.Request) {
-    var userID int
-    var err error
-
-    // Extract and validate token from the header
-    tokenString := r.Header.Get("Authorization")
-    if tokenString == "" {
-        respondWithError(w, http.StatusUnauthorized, "Missing authorization token")
-        return
-    }
-    tokenString = strings.TrimSpace(strings.TrimPrefix(tokenString, "Bearer "))
-    if tokenString == "" {
-        respondWithError(w, http.StatusUnauthorized, "Invalid token format")
-        return
-    }
-
-    // Parse the user ID from the token
-    userID, err = utils.ParseToken(tokenString)
-    if err != nil {
-        respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
-        return
-    }
+// GetStory lists every story of the authenticated user, or only those
+// carrying the tag named by the tag query parameter.
+func (h *Handler) GetStory(w http.ResponseWriter, r *http.Request) {
+    userID := currentPrincipal(r).UserID
+    tag := normalizeTagName(r.URL.Query().Get("tag"))
 
     // Retrieve all stories and their story IDs for the given user ID
-    rows, err := db.Query("SELECT id, stories FROM usersStory WHERE userId = ?", userID)
+    userStories, err := h.stories.ListByUser(r.Context(), userID)
     if err != nil {
         respondWithError(w, http.StatusInternalServerError, "Failed to retrieve stories")
         return
     }
-    defer rows.Close()
 
     // Collect all stories and their IDs in a slice
     var stories []map[string]interface{}
-    for rows.Next() {
-        var storyID int
-        var storyData sql.NullString
-        if err := rows.Scan(&storyID, &storyData); err != nil {
-            respondWithError(w, http.StatusInternalServerError, "Failed to read story")
-            return
-        }
-
-        // Unmarshal JSON data if valid
-        if storyData.Valid {
-            var story map[string]interface{}
-            if err := json.Unmarshal([]byte(storyData.String), &story); err != nil {
-                respondWithError(w, http.StatusInternalServerError, "Failed to parse story data")
-                return
-            }
-            // Add the storyId to the story map
-            story["storyId"] = storyID
-            stories = append(stories, story)
+    for _, story := range userStories {
+        if tag != "" && !hasTag(story.Tags, tag) {
+            continue
         }
+        // Add the storyId and storyStatus to the story map
+        stories = append(stories, storyDocument(story))
     }
 
     // Send the response with all stories and their IDs for the user
@@ -126,4 +87,209 @@ func GetStory(w http.ResponseWriter, r *http.Request) {
         Data:    stories,
     }
     respondWithJSON(w, http.StatusOK, successResponse)
-}
\ No newline at end of file
+}
+
+// GetStoryByID returns a single story owned by the authenticated user.
+func (h *Handler) GetStoryByID(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story retrieved successfully",
+		Data:    storyDocument(story),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// UpdateStory replaces the whole story document of a story owned by
+// the authenticated user.
+//
+// The request body has the same shape as the one accepted by AddStory,
+// except that the type and the tags may be left out to keep the current
+// ones. Legacy stories can be given a type this way, but no story can be
+// made legacy. A published story edited by its author goes back to
+// review.
+func (h *Handler) UpdateStory(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AddStoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Story == nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	storyType := story.Type
+	if req.Type != "" && req.Type != story.Type {
+		if !h.requireStoryType(w, req.Type) {
+			return
+		}
+		storyType = req.Type
+	}
+	content := storyContent(req.Story)
+	if !h.validateStory(w, storyType, content) {
+		return
+	}
+	var tags []string
+	if req.Tags != nil {
+		if tags, ok = requireTags(w, req.Tags); !ok {
+			return
+		}
+	}
+
+	story, _, ok = h.saveStory(w, r, story, models.StoryRevision{Type: storyType, Content: content}, tags)
+	if !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story updated successfully",
+		Data:    storyDocument(story),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// PatchStory applies a JSON merge patch (RFC 7396) to a story owned by
+// the authenticated user.
+//
+// The request body is the patch document itself: fields set to null are
+// removed from the story, objects are merged recursively and every other
+// value replaces the stored one. The result must still match the story's
+// type, which a patch cannot change. A published story edited by its
+// author goes back to review.
+func (h *Handler) PatchStory(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid merge patch document")
+		return
+	}
+
+	patched, _ := utils.MergePatch(story.Content, patch).(map[string]interface{})
+	patched = storyContent(patched)
+	if !h.validateStory(w, story.Type, patched) {
+		return
+	}
+
+	story, _, ok = h.saveStory(w, r, story, models.StoryRevision{Type: story.Type, Content: patched}, nil)
+	if !ok {
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story updated successfully",
+		Data:    storyDocument(story),
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// DeleteStory removes a story owned by the authenticated user.
+func (h *Handler) DeleteStory(w http.ResponseWriter, r *http.Request) {
+	story, ok := h.loadOwnedStory(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.stories.Delete(r.Context(), story.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete story")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Story deleted successfully",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// loadOwnedStory reads the story named by the {id} route variable and
+// makes sure it belongs to the authenticated user, unless their role may
+// manage every story.
+//
+// It writes a 400, 404 or 403 error response and returns false when the
+// story cannot be used by the caller.
+func (h *Handler) loadOwnedStory(w http.ResponseWriter, r *http.Request) (models.Story, bool) {
+	storyID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || storyID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid story ID")
+		return models.Story{}, false
+	}
+
+	story, err := h.stories.FindByID(r.Context(), storyID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Story not found")
+		return models.Story{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve story")
+		return models.Story{}, false
+	}
+	principal := currentPrincipal(r)
+	if story.UserID != principal.UserID && !principal.Can(models.PermissionManageStories) {
+		respondWithError(w, http.StatusForbidden, "You are not allowed to access this story")
+		return models.Story{}, false
+	}
+	return story, true
+}
+
+// saveStory overwrites the type and JSON document of story with those of
+// rev and records them as a new revision written by the authenticated
+// user. When tags is not nil, they replace the tags of the story in the
+// same write. It returns the story as now stored along with the
+// revision; its tags keep the spelling of the ones that already existed.
+//
+// A published story edited by its author goes back to review in the same
+// write, so changes are reviewed before they are shown publicly. Edits by
+// anyone else leave the status alone.
+func (h *Handler) saveStory(w http.ResponseWriter, r *http.Request, story models.Story, rev models.StoryRevision, tags []string) (models.Story, models.StoryRevision, bool) {
+	principal := currentPrincipal(r)
+	rev.StoryID, rev.AuthorID = story.ID, principal.UserID
+
+	var transition *models.StoryTransition
+	if story.Status == models.StoryPublished && story.UserID == principal.UserID {
+		transition = &models.StoryTransition{
+			StoryID:   story.ID,
+			From:      story.Status,
+			To:        models.StoryPendingReview,
+			ActorID:   principal.UserID,
+			Reason:    "Edited after publication",
+			CreatedAt: time.Now().UTC(),
+		}
+	}
+
+	rev, err := h.stories.Update(r.Context(), rev, tags, transition)
+	if errors.Is(err, repositories.ErrStatusChanged) {
+		respondWithError(w, http.StatusConflict, "The story status was changed meanwhile, please reload it")
+		return story, rev, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to store story")
+		return story, rev, false
+	}
+
+	story.Type, story.Content = rev.Type, rev.Content
+	if transition != nil {
+		story.Status = transition.To
+	}
+	if tags != nil {
+		stored, err := h.stories.FindByID(r.Context(), story.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve story")
+			return story, rev, false
+		}
+		story.Tags = stored.Tags
+	}
+	return story, rev, true
+}
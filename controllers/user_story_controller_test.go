@@ -0,0 +1,128 @@
+package controllers_test
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"blog_project.com/controllers"
+)
+
+// storyDocument is the API view of a story.
+type storyDocument struct {
+	ID      int      `json:"storyId"`
+	Status  string   `json:"storyStatus"`
+	Type    string   `json:"storyType"`
+	Tags    []string `json:"storyTags"`
+	Title   string   `json:"title"`
+	Body    string   `json:"body"`
+	Summary string   `json:"summary"`
+}
+
+// addStory adds a blog post as the owner of token and returns it, as
+// listed by GET /api/get-story.
+func (s *testServer) addStory(token, title string, tags ...string) storyDocument {
+	s.t.Helper()
+	s.expect(http.StatusOK, "POST", "/api/add-story", token, map[string]interface{}{
+		"type":  "blog_post",
+		"story": map[string]interface{}{"title": title, "body": "Body of " + title},
+		"tags":  tags,
+	})
+
+	var stories []storyDocument
+	s.expect(http.StatusOK, "GET", "/api/get-story", token, nil).decode(s.t, &stories)
+	for _, story := range stories {
+		if story.Title == title {
+			return story
+		}
+	}
+	s.t.Fatalf("story %q is not listed in %+v", title, stories)
+	return storyDocument{}
+}
+
+func storyPath(id int) string {
+	return "/api/stories/" + strconv.Itoa(id)
+}
+
+func TestStoryCRUD(t *testing.T) {
+	forEachBackend(t, controllers.Options{}, func(t *testing.T, s *testServer) {
+		s.createUser("fox@example.com", "user")
+		s.createUser("dana@example.com", "user")
+		owner, other := s.login("fox@example.com"), s.login("dana@example.com")
+
+		story := s.addStory(owner, "Trust no one", "Aliens", "cover-up")
+		if story.Status != "draft" || story.Type != "blog_post" || !reflect.DeepEqual(story.Tags, []string{"Aliens", "cover-up"}) {
+			t.Errorf("added story = %+v", story)
+		}
+		path := storyPath(story.ID)
+
+		var got storyDocument
+		s.expect(http.StatusOK, "GET", path, owner, nil).decode(t, &got)
+		if got.Title != "Trust no one" || got.Body != "Body of Trust no one" {
+			t.Errorf("GET %s = %+v", path, got)
+		}
+		if code, resp := s.do("GET", path, other, nil); code != http.StatusForbidden {
+			t.Errorf("GET of another user's story = %d %q, want 403", code, resp.Message)
+		}
+
+		s.expect(http.StatusOK, "PUT", path, owner, map[string]interface{}{
+			"story": map[string]interface{}{"title": "I want to believe", "body": "New body"},
+			"tags":  []string{"aliens", "X-Files"},
+		}).decode(t, &got)
+		if got.Title != "I want to believe" || !reflect.DeepEqual(got.Tags, []string{"Aliens", "X-Files"}) {
+			t.Errorf("PUT %s = %+v, want the new title and the stored tag spelling", path, got)
+		}
+		if code, resp := s.do("PUT", path, owner, map[string]interface{}{"story": map[string]interface{}{"title": ""}}); code != http.StatusUnprocessableEntity {
+			t.Errorf("PUT of an invalid story = %d %q, want 422", code, resp.Message)
+		}
+		if code, resp := s.do("PUT", path, other, map[string]interface{}{"story": map[string]interface{}{"title": "Mine", "body": "x"}}); code != http.StatusForbidden {
+			t.Errorf("PUT of another user's story = %d %q, want 403", code, resp.Message)
+		}
+
+		code, resp := s.send("PATCH", path, owner, "application/merge-patch+json", bytes.NewBufferString(`{"summary": "Short", "body": "Patched"}`))
+		if code != http.StatusOK {
+			t.Fatalf("PATCH %s = %d %q", path, code, resp.Message)
+		}
+		resp.decode(t, &got)
+		if got.Title != "I want to believe" || got.Body != "Patched" || got.Summary != "Short" || len(got.Tags) != 2 {
+			t.Errorf("PATCH %s = %+v", path, got)
+		}
+
+		if code, resp := s.do("DELETE", path, other, nil); code != http.StatusForbidden {
+			t.Errorf("DELETE of another user's story = %d %q, want 403", code, resp.Message)
+		}
+		s.expect(http.StatusOK, "DELETE", path, owner, nil)
+		if code, resp := s.do("GET", path, owner, nil); code != http.StatusNotFound {
+			t.Errorf("GET of a deleted story = %d %q, want 404", code, resp.Message)
+		}
+	})
+}
+
+func TestStoryRevisions(t *testing.T) {
+	forEachBackend(t, controllers.Options{}, func(t *testing.T, s *testServer) {
+		s.createUser("fox@example.com", "user")
+		token := s.login("fox@example.com")
+
+		story := s.addStory(token, "First")
+		path := storyPath(story.ID)
+		s.expect(http.StatusOK, "PUT", path, token, map[string]interface{}{
+			"story": map[string]interface{}{"title": "Second", "body": "Body of First"},
+		})
+
+		var revisions []struct {
+			Revision int `json:"revision"`
+		}
+		s.expect(http.StatusOK, "GET", path+"/revisions", token, nil).decode(t, &revisions)
+		if len(revisions) != 2 {
+			t.Fatalf("revisions = %+v, want 2", revisions)
+		}
+
+		var got storyDocument
+		s.expect(http.StatusOK, "POST", path+"/revisions/1/restore", token, nil).decode(t, &got)
+		if got.Title != "First" {
+			t.Errorf("restored story = %+v, want the first title", got)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"blog_project.com/mail"
+	"blog_project.com/models"
+	"blog_project.com/repositories"
+	"blog_project.com/utils"
+)
+
+// errVerificationRateLimited is returned by sendVerification when the
+// account was sent a verification mail too recently.
+var errVerificationRateLimited = errors.New("verification mail sent too recently")
+
+// VerifyEmail marks the email of an account as verified using the signed
+// token from a verification mail.
+//
+// Links are bound to the address they were sent to, so a link stops
+// working once the account's email is changed.
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusBadRequest, "Verification token is mandatory")
+		return
+	}
+
+	userID, email, err := utils.ParseVerificationToken(token)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired verification link")
+		return
+	}
+
+	verified, err := h.users.MarkEmailVerified(r.Context(), userID, email, time.Now().UTC())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to verify email, please try again")
+		return
+	}
+	if !verified {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired verification link")
+		return
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "Email verified successfully",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// ResendVerification mails a new verification link to an unverified
+// account.
+//
+// Like ForgotPassword it answers the same way whatever the address, so
+// it neither reveals which emails are registered nor whether the resend
+// was rate limited. Mail failures are only logged, for the same reason.
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req models.ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		respondWithError(w, http.StatusBadRequest, "Email is mandatory")
+		return
+	}
+
+	ctx := r.Context()
+	user, err := h.users.FindByEmail(ctx, strings.TrimSpace(req.Email))
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send verification mail, please try again")
+		return
+	}
+	if err == nil && user.EmailVerifiedAt == nil && user.DeletedAt == nil {
+		err := h.sendVerification(ctx, user, true)
+		if err != nil && !errors.Is(err, errVerificationRateLimited) {
+			log.Printf("Failed to send verification mail to user %d: %v", user.ID, err)
+		}
+	}
+
+	successResponse := models.Response{
+		Status:  true,
+		Message: "If the email is registered and not yet verified, a verification link has been sent to it",
+		Data:    struct{}{},
+	}
+	respondWithJSON(w, http.StatusOK, successResponse)
+}
+
+// sendVerification mails user a signed link that verifies their current
+// email. When rateLimited is set it returns errVerificationRateLimited,
+// without sending anything, if the previous link went out less than
+// VerificationResendInterval ago.
+func (h *Handler) sendVerification(ctx context.Context, user models.RegisterUserModel, rateLimited bool) error {
+	now := time.Now().UTC()
+	notBefore := now
+	if rateLimited {
+		notBefore = now.Add(-h.opts.VerificationResendInterval)
+	}
+	claimed, err := h.users.ClaimVerificationSend(ctx, user.ID, now, notBefore)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errVerificationRateLimited
+	}
+
+	token, err := utils.SignVerificationToken(user.ID, user.Email, now.Add(h.opts.EmailVerificationTTL))
+	if err != nil {
+		return err
+	}
+	link, err := withQuery(h.opts.EmailVerificationURL, "token", token)
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that this is your email address by opening the link below:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s. If you did not create an account, you can ignore this mail.\n",
+			user.FullName, link, h.opts.EmailVerificationTTL),
+	})
+}
+
+// requireVerifiedEmail writes a 403 and returns false when the
+// authenticated user has not verified their email and the configuration
+// requires it for posting.
+func (h *Handler) requireVerifiedEmail(w http.ResponseWriter, r *http.Request) bool {
+	if h.opts.AllowUnverifiedPosting {
+		return true
+	}
+	user, ok := h.loadCurrentUser(w, r)
+	if !ok {
+		return false
+	}
+	if user.EmailVerifiedAt == nil {
+		respondWithError(w, http.StatusForbidden, "Please verify your email before posting stories")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+// Package imaging prepares uploaded pictures for serving.
+//
+// Every picture is decoded and encoded again, which drops EXIF, GPS and
+// any other metadata the original file carried. The EXIF orientation is
+// applied to the pixels first so photos taken in portrait stay upright.
+// Square thumbnails are produced with the standard image packages only.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"path"
+	"strings"
+)
+
+// ThumbnailSizes are the edge lengths, in pixels, of the square variants
+// generated for every profile picture.
+var ThumbnailSizes = []int{64, 128, 512}
+
+// MaxPixels caps the decoded size of a picture so a small, highly
+// compressed file cannot exhaust memory when it is decoded.
+const MaxPixels = 40_000_000
+
+// jpegQuality is used for every JPEG the package writes.
+const jpegQuality = 85
+
+// ErrInvalidImage is returned for data that cannot be decoded as one of
+// the supported formats or that exceeds MaxPixels.
+var ErrInvalidImage = errors.New("imaging: invalid image")
+
+// Image is a processed picture ready to be stored.
+type Image struct {
+	// ContentType and Ext describe the format every variant is encoded in:
+	// JPEG for JPEG uploads and PNG otherwise, so transparency survives.
+	ContentType string
+	Ext         string
+	// Original is the full-size picture without metadata.
+	Original []byte
+	// Thumbnails holds one square picture per requested size.
+	Thumbnails map[int][]byte
+}
+
+// Process decodes data, strips its metadata and renders a square
+// thumbnail for each of sizes.
+func Process(data []byte, sizes []int) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrInvalidImage, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	src := toRGBA(img)
+	if format == "jpeg" {
+		src = orient(src, exifOrientation(data))
+	}
+
+	out := &Image{ContentType: "image/png", Ext: ".png", Thumbnails: map[int][]byte{}}
+	encode := encodePNG
+	if format == "jpeg" {
+		out.ContentType, out.Ext, encode = "image/jpeg", ".jpg", encodeJPEG
+	}
+
+	if out.Original, err = encode(src); err != nil {
+		return nil, err
+	}
+	for _, size := range sizes {
+		if out.Thumbnails[size], err = encode(square(src, size)); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// VariantKey returns the key the thumbnail of the given size is stored
+// under, next to the original stored under key.
+func VariantKey(key string, size int) string {
+	ext := path.Ext(key)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(key, ext), size, ext)
+}
+
+// toRGBA copies img into a premultiplied RGBA image with its origin at
+// (0, 0).
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+func encodeJPEG(img *image.RGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodePNG(img *image.RGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,58 @@
+package imaging
+
+import "image"
+
+// square crops the centre square out of src and scales it to size×size.
+//
+// Every destination pixel is the average of the source pixels it
+// covers, which keeps downscaled photos free of aliasing. When the
+// source is smaller than size, pixels are simply repeated.
+func square(src *image.RGBA, size int) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	offX := (b.Dx() - side) / 2
+	offY := (b.Dy() - side) / 2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		y0, y1 := span(dy, side, size)
+		for dx := 0; dx < size; dx++ {
+			x0, x1 := span(dx, side, size)
+
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := src.Pix[(offY+y)*src.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[(offX+x)*4:]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			d := dst.Pix[dy*dst.Stride+dx*4:]
+			d[0] = uint8(r / n)
+			d[1] = uint8(g / n)
+			d[2] = uint8(bl / n)
+			d[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// span returns the half-open range of source pixels covered by
+// destination pixel i when side source pixels are mapped onto size
+// destination pixels. The range is never empty.
+func span(i, side, size int) (int, int) {
+	start := i * side / size
+	end := (i + 1) * side / size
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
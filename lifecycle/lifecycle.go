@@ -0,0 +1,90 @@
+// Package lifecycle coordinates background workers and the ordered
+// shutdown of the resources the server depends on.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Hook releases a resource. It should give up when ctx is done.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// Manager runs shutdown hooks in the reverse order they were registered,
+// so a resource registered early (the database pool) is only closed once
+// everything registered after it (workers, the HTTP server) has stopped.
+type Manager struct {
+	mu           sync.Mutex
+	hooks        []namedHook
+	shuttingDown bool
+}
+
+// New returns an empty Manager.
+func New() *Manager {
+	return &Manager{}
+}
+
+// OnShutdown registers a hook to run during Shutdown.
+func (m *Manager) OnShutdown(name string, hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, namedHook{name: name, hook: hook})
+}
+
+// Go starts a background worker and registers a shutdown hook that
+// cancels the worker's context and waits for it to return.
+func (m *Manager) Go(name string, worker func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker(ctx)
+	}()
+
+	m.OnShutdown(name, func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	})
+}
+
+// ShuttingDown reports whether Shutdown has been called.
+func (m *Manager) ShuttingDown() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shuttingDown
+}
+
+// Shutdown runs every registered hook, most recently registered first.
+//
+// A failing hook does not stop the remaining ones; all errors are
+// returned together.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.shuttingDown = true
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		log.Printf("Shutting down %s", h.name)
+		if err := h.hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Log prints every message to the standard logger instead of sending
+// it. It is meant for local development.
+type Log struct {
+	from string
+}
+
+// NewLog returns a Log mailer.
+func NewLog(from string) *Log {
+	return &Log{from: from}
+}
+
+// Send implements Mailer.
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// File writes every message to its own .eml file in a directory instead
+// of sending it. It is meant for local development and tests.
+type File struct {
+	dir  string
+	from string
+}
+
+// NewFile returns a File mailer writing to dir, creating it if needed.
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+// Send implements Mailer.
+func (f *File) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	now := time.Now()
+	file, err := os.CreateTemp(f.dir, fmt.Sprintf("%s-*.eml", now.UTC().Format("20060102T150405")))
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(format(f.from, msg, now)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,73 @@
+// Package mail sends transactional email through the Mailer interface.
+//
+// Production sends through an SMTP relay. For local development the log
+// mailer prints every message and the file mailer writes each one to an
+// .eml file, so links in password reset mails can be followed without a
+// mail server.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"blog_project.com/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer selected by cfg.
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTP(SMTPOptions{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		}), nil
+	case "file":
+		return NewFile(cfg.File.Dir, cfg.From)
+	case "log":
+		return NewLog(cfg.From), nil
+	default:
+		return nil, fmt.Errorf("mail: unsupported driver %q", cfg.Driver)
+	}
+}
+
+// format renders msg as an RFC 5322 message from the given sender.
+func format(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// validHeader reports whether a header value is free of line breaks,
+// which would let it inject extra headers.
+func validHeader(values ...string) error {
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("mail: header value %q contains a line break", value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPOptions configures an SMTP mailer.
+type SMTPOptions struct {
+	Host string
+	Port int
+	// Username and Password enable PLAIN authentication when set. The
+	// standard library only sends them over TLS or to localhost.
+	Username string
+	Password string
+	From     string
+}
+
+// SMTP sends mail through an SMTP relay, upgrading the connection with
+// STARTTLS when the server offers it.
+type SMTP struct {
+	opts SMTPOptions
+}
+
+// NewSMTP returns an SMTP mailer.
+func NewSMTP(opts SMTPOptions) *SMTP {
+	return &SMTP{opts: opts}
+}
+
+// Send implements Mailer.
+//
+// net/smtp has no context support, so ctx is only checked before the
+// message is handed over.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.opts.Username != "" {
+		auth = smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.opts.Host)
+	}
+	addr := net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port))
+	return smtp.SendMail(addr, auth, s.opts.From, []string{msg.To}, format(s.opts.From, msg, time.Now()))
+}
@@ -1,27 +1,152 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"blog_project.com/config"
 	"blog_project.com/controllers"
+	"blog_project.com/lifecycle"
+	"blog_project.com/mail"
+	"blog_project.com/repositories"
 	"blog_project.com/routers"
+	"blog_project.com/storage"
+	"blog_project.com/utils"
 )
 
 func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run starts the server, or the subcommand named in args, and returns
+// once it has stopped and every resource has been released.
+func run(args []string) error {
+	// Load and validate the configuration
+	cfg, args, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+	utils.ConfigureTokens(cfg.Auth.JWTSecret, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+
 	// Initialize the database
-	controllers.InitDB()
-	defer controllers.DB.Close()
-	controllers.Initialize(controllers.DB)
+	db, err := repositories.Open(cfg.Database.Driver, cfg.Database.DSN)
+	if err != nil {
+		return err
+	}
+
+	// Run a subcommand instead of the server when one is given
+	if len(args) > 0 {
+		defer db.Close()
+		switch args[0] {
+		case "migrate":
+			return runMigrate(db, cfg.Database.Driver, args[1:])
+		case "admin":
+			return runAdmin(db, args[1:])
+		case "search":
+			return runSearch(db, args[1:])
+		default:
+			return errors.New("unknown command " + args[0])
+		}
+	}
+
+	// Resources are released in the reverse order they are registered:
+	// the HTTP server drains first, then background workers stop and the
+	// database pool is closed last.
+	lc := lifecycle.New()
+	lc.OnShutdown("database", func(ctx context.Context) error { return db.Close() })
+
+	blobs, err := storage.New(cfg.Storage)
+	if err != nil {
+		lc.Shutdown(context.Background())
+		return err
+	}
 
-   
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		lc.Shutdown(context.Background())
+		return err
+	}
+
+	uploads := storage.NewUploader(blobs, cfg.Storage.MaxUploadBytes, storage.ImageTypes)
+	handler, err := controllers.NewHandler(repositories.New(db), uploads, mailer, controllers.Options{
+		DeletionGracePeriod:        cfg.Accounts.DeletionGracePeriod,
+		PasswordResetURL:           cfg.Accounts.PasswordResetURL,
+		PasswordResetTTL:           cfg.Accounts.PasswordResetTTL,
+		EmailVerificationURL:       cfg.Accounts.EmailVerificationURL,
+		EmailVerificationTTL:       cfg.Accounts.EmailVerificationTTL,
+		VerificationResendInterval: cfg.Accounts.VerificationResendInterval,
+		AllowUnverifiedLogin:       cfg.Accounts.AllowUnverifiedLogin,
+		AllowUnverifiedPosting:     cfg.Accounts.AllowUnverifiedPosting,
+		CommentEditWindow:          cfg.Comments.EditWindow,
+	})
+	if err != nil {
+		lc.Shutdown(context.Background())
+		return err
+	}
+	lc.Go("handler background jobs", handler.Run)
 
-	// Get the configured router with API and static file handling
-	handler := routers.SetupRouter()
+	// Get the configured router with API, probe and static file handling
+	health := controllers.NewHealthHandler(db, blobs, lc.ShuttingDown)
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           routers.SetupRouter(cfg, handler, health),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+	lc.OnShutdown("http server", server.Shutdown)
+
+	// Registered last so it runs first: /readyz already fails at this
+	// point, and load balancers get DrainDelay to stop routing traffic
+	// here before the listener closes.
+	lc.OnShutdown("readiness drain", func(ctx context.Context) error {
+		select {
+		case <-time.After(cfg.Server.DrainDelay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
 
 	// Start the server
-	log.Println("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", handler); err != nil {
-		log.Fatal("Server failed to start:", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Starting server on %s (%s)", cfg.Server.Addr, cfg.Env)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	// Wait for SIGINT/SIGTERM or for the server to fail on its own
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err = <-serverErr:
+		log.Println("Server failed:", err)
+	case <-ctx.Done():
+		log.Println("Shutdown signal received, draining in-flight requests")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if shutdownErr := lc.Shutdown(shutdownCtx); shutdownErr != nil {
+		err = errors.Join(err, shutdownErr)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	if err == nil {
+		log.Println("Server stopped")
 	}
+	return err
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"blog_project.com/migrations"
+)
+
+// runMigrate implements the "migrate up|down|status" subcommands.
+//
+//	up      apply every pending migration
+//	down    roll back the most recently applied migration
+//	status  list migrations and whether they have been applied
+func runMigrate(db *sql.DB, driver string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: migrate up|down|status")
+	}
+
+	migrator, err := migrations.New(db, driver)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+	case "down":
+		m, ok, err := migrator.Down()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Println("No migration to roll back")
+			return nil
+		}
+		log.Printf("Rolled back migration %04d_%s", m.Version, m.Name)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+// Package migrations versions the database schema.
+//
+// Migrations are plain SQL files embedded into the binary and named
+// <version>_<name>.up.sql / <version>_<name>.down.sql, where version is a
+// positive integer. Every supported database driver has its own
+// directory of migrations; both must define the same versions. Applied
+// versions are recorded in the schema_migrations table so every
+// migration runs exactly once. The few migrations that need more than SQL
+// also have a Go step, which runs after their scripts.
+//
+// On SQLite a migration and the record of it are committed together, so
+// a failing migration leaves nothing behind. MySQL commits every schema
+// change on its own, so a migration failing halfway has to be cleaned up
+// by hand there.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// Migration is a single schema change with its rollback.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+
+	// step, when set, runs after both the up and the down script, for
+	// what plain SQL cannot do. See steps.
+	step func(q execer) error
+}
+
+// Status describes whether a migration has been applied.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies embedded migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	// transactional is set for databases that can roll back schema
+	// changes.
+	transactional bool
+}
+
+// execer runs statements and queries on a *sql.DB or within a *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// New returns a Migrator for db loaded with the embedded migrations
+// written for driver ("mysql" or "sqlite").
+func New(db *sql.DB, driver string) (*Migrator, error) {
+	if _, err := fs.Stat(files, driver); err != nil {
+		return nil, fmt.Errorf("migrations: no migrations for database driver %q", driver)
+	}
+	sub, err := fs.Sub(files, driver)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Load(sub)
+	if err != nil {
+		return nil, err
+	}
+	for i := range migrations {
+		migrations[i].step = steps[driver][migrations[i].Version]
+	}
+	return &Migrator{db: db, migrations: migrations, transactional: driver == "sqlite"}, nil
+}
+
+// Load reads every migration in the root of fsys, ordered by version.
+//
+// Each version must have both an up and a down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".sql" {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		if direction != ".up" && direction != ".down" {
+			return nil, fmt.Errorf("migrations: %s must end in .up.sql or .down.sql", name)
+		}
+
+		versionPart, label, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionPart)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrations: %s does not start with a positive version number", name)
+		}
+
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migrations: version %d is used by both %q and %q", version, m.Name, label)
+		}
+		if direction == ".up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrations: version %d (%s) needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the ones it
+// applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.inTx(func(q execer) error {
+			if err := run(q, migration.Up, migration.step); err != nil {
+				return fmt.Errorf("migrations: applying %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := q.Exec(
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				migration.Version, migration.Name, time.Now().UTC(),
+			); err != nil {
+				return fmt.Errorf("migrations: recording %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the most recently applied migration. It returns false
+// when there is nothing to roll back.
+func (m *Migrator) Down() (Migration, bool, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return Migration{}, false, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.inTx(func(q execer) error {
+			if err := run(q, migration.Down, migration.step); err != nil {
+				return fmt.Errorf("migrations: rolling back %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := q.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version); err != nil {
+				return fmt.Errorf("migrations: unrecording %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return migration, false, err
+		}
+		return migration, true, nil
+	}
+	return Migration{}, false, nil
+}
+
+// Status lists every known migration with the time it was applied, if
+// it was.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			at := at
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// applied creates the bookkeeping table if needed and returns the
+// applied versions with their timestamps.
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	if _, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INT          NOT NULL PRIMARY KEY,
+		name       VARCHAR(255) NOT NULL,
+		applied_at DATETIME     NOT NULL
+	)`); err != nil {
+		return nil, fmt.Errorf("migrations: creating schema_migrations: %w", err)
+	}
+
+	rows, err := m.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// inTx calls fn within a transaction on databases that can roll back
+// schema changes, and directly on the pool on the others.
+func (m *Migrator) inTx(fn func(q execer) error) error {
+	if !m.transactional {
+		return fn(m.db)
+	}
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// run executes every statement of a migration script in order, then
+// step if it is set.
+//
+// Statements are executed one by one because the MySQL driver rejects
+// multi-statement queries unless the DSN enables them.
+func run(q execer, script string, step func(q execer) error) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := q.Exec(statement); err != nil {
+			return err
+		}
+	}
+	if step != nil {
+		return step(q)
+	}
+	return nil
+}
+
+// splitStatements splits a SQL script on semicolons that end a line,
+// dropping comment-only lines.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
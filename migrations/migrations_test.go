@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"path/filepath"
+	"testing"
+
+	"blog_project.com/repositories"
+)
+
+func TestSQLiteMigrationsRoundTrip(t *testing.T) {
+	db, err := repositories.Open(repositories.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m, err := New(db, repositories.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Fatalf("Up applied %d of %d migrations", len(applied), len(m.migrations))
+	}
+	for range m.migrations {
+		if _, ok, err := m.Down(); err != nil || !ok {
+			t.Fatalf("Down: %v, %v", ok, err)
+		}
+	}
+	if _, ok, err := m.Down(); err != nil || ok {
+		t.Fatalf("Down with nothing applied: %v, %v", ok, err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("Up after rolling everything back: %v", err)
+	}
+}
+
+func TestDriversDefineTheSameVersions(t *testing.T) {
+	mysql, err := New(nil, "mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := New(nil, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mysql.migrations) != len(sqlite.migrations) {
+		t.Fatalf("mysql has %d migrations, sqlite %d", len(mysql.migrations), len(sqlite.migrations))
+	}
+	for i := range mysql.migrations {
+		if a, b := mysql.migrations[i], sqlite.migrations[i]; a.Version != b.Version || a.Name != b.Name {
+			t.Errorf("migration %d is %d_%s on mysql and %d_%s on sqlite", i, a.Version, a.Name, b.Version, b.Name)
+		}
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS usersStory;
+DROP TABLE IF EXISTS users;
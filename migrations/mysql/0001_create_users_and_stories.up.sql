@@ -0,0 +1,21 @@
+-- Tables the API has always relied on. IF NOT EXISTS lets environments
+-- that created them by hand adopt the migrator without losing data; 0017
+-- then adds the constraints below that such tables lack.
+CREATE TABLE IF NOT EXISTS users (
+    id          INT          NOT NULL AUTO_INCREMENT,
+    full_name   VARCHAR(255) NOT NULL,
+    email       VARCHAR(255) NOT NULL,
+    password    VARCHAR(255) NOT NULL,
+    profile_pic VARCHAR(512) NOT NULL DEFAULT '',
+    PRIMARY KEY (id),
+    UNIQUE KEY users_email_unique (email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS usersStory (
+    id      INT  NOT NULL AUTO_INCREMENT,
+    stories JSON NULL,
+    userId  INT  NOT NULL,
+    PRIMARY KEY (id),
+    KEY usersStory_userId_index (userId),
+    CONSTRAINT usersStory_userId_fk FOREIGN KEY (userId) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
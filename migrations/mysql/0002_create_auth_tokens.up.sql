@@ -0,0 +1,35 @@
+-- Refresh tokens are stored as SHA-256 hashes and grouped into families,
+-- one per login, so a replayed token can revoke the whole family.
+CREATE TABLE refresh_tokens (
+    id         INT         NOT NULL AUTO_INCREMENT,
+    user_id    INT         NOT NULL,
+    family_id  VARCHAR(64) NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at DATETIME    NOT NULL,
+    created_at DATETIME    NOT NULL,
+    used_at    DATETIME    NULL,
+    revoked_at DATETIME    NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY refresh_tokens_hash_unique (token_hash),
+    KEY refresh_tokens_family_index (family_id),
+    KEY refresh_tokens_user_index (user_id),
+    CONSTRAINT refresh_tokens_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Access tokens revoked before their exp claim, kept until they expire.
+CREATE TABLE revoked_tokens (
+    jti        VARCHAR(64) NOT NULL,
+    user_id    INT         NOT NULL,
+    expires_at DATETIME    NOT NULL,
+    revoked_at DATETIME    NOT NULL,
+    PRIMARY KEY (jti),
+    KEY revoked_tokens_expires_index (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Per-user cut-off: access tokens issued before revoked_before are refused.
+CREATE TABLE user_token_revocations (
+    user_id        INT      NOT NULL,
+    revoked_before DATETIME NOT NULL,
+    expires_at     DATETIME NOT NULL,
+    PRIMARY KEY (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+DROP INDEX users_deleted_at_index ON users;
+
+ALTER TABLE users DROP COLUMN deleted_at;
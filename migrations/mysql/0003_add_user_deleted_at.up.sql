@@ -0,0 +1,5 @@
+-- Accounts deleted by their owner are kept for a grace period before they
+-- are purged; deleted_at marks when the deletion was requested.
+ALTER TABLE users ADD COLUMN deleted_at DATETIME NULL;
+
+CREATE INDEX users_deleted_at_index ON users (deleted_at);
@@ -0,0 +1,13 @@
+-- Single-use password reset tokens, stored as SHA-256 hashes.
+CREATE TABLE password_reset_tokens (
+    id         INT      NOT NULL AUTO_INCREMENT,
+    user_id    INT      NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    used_at    DATETIME NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY password_reset_tokens_hash_unique (token_hash),
+    KEY password_reset_tokens_user_index (user_id),
+    CONSTRAINT password_reset_tokens_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
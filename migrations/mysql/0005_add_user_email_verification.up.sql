@@ -0,0 +1,8 @@
+-- email_verified_at stays NULL until the owner follows the link mailed
+-- to them; verification_sent_at rate limits resending that link.
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL;
+
+ALTER TABLE users ADD COLUMN verification_sent_at DATETIME NULL;
+
+-- Accounts registered before verification existed keep working.
+UPDATE users SET email_verified_at = UTC_TIMESTAMP();
@@ -0,0 +1,3 @@
+DROP INDEX users_role_index ON users;
+
+ALTER TABLE users DROP COLUMN role;
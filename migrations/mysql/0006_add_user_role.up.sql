@@ -0,0 +1,5 @@
+-- Every account has exactly one role; the permissions of each role are
+-- defined in code.
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+
+CREATE INDEX users_role_index ON users (role);
@@ -0,0 +1,3 @@
+DROP INDEX users_disabled_at_index ON users;
+
+ALTER TABLE users DROP COLUMN disabled_at;
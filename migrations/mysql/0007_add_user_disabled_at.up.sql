@@ -0,0 +1,5 @@
+-- Accounts disabled by an admin cannot log in or use their tokens until
+-- they are enabled again; disabled_at marks when that happened.
+ALTER TABLE users ADD COLUMN disabled_at DATETIME NULL;
+
+CREATE INDEX users_disabled_at_index ON users (disabled_at);
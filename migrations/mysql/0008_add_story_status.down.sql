@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS story_status_transitions;
+
+DROP INDEX usersStory_status_index ON usersStory;
+
+ALTER TABLE usersStory DROP COLUMN status;
@@ -0,0 +1,20 @@
+-- Stories go through review before they are shown publicly. Existing
+-- stories were private to their authors, so they start as drafts.
+ALTER TABLE usersStory ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft';
+
+CREATE INDEX usersStory_status_index ON usersStory (status);
+
+-- Every status change, with who made it and why.
+CREATE TABLE story_status_transitions (
+    id          INT         NOT NULL AUTO_INCREMENT,
+    story_id    INT         NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status   VARCHAR(20) NOT NULL,
+    actor_id    INT         NULL,
+    reason      TEXT        NULL,
+    created_at  DATETIME    NOT NULL,
+    PRIMARY KEY (id),
+    KEY story_status_transitions_story_index (story_id),
+    CONSTRAINT story_status_transitions_story_fk FOREIGN KEY (story_id) REFERENCES usersStory (id) ON DELETE CASCADE,
+    CONSTRAINT story_status_transitions_actor_fk FOREIGN KEY (actor_id) REFERENCES users (id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS story_likes;
+
+DROP INDEX usersStory_feed_newest_index ON usersStory;
+
+ALTER TABLE usersStory DROP COLUMN created_at;
@@ -0,0 +1,20 @@
+-- created_at orders the public feed. Stories written before it existed
+-- get the time of the migration.
+ALTER TABLE usersStory ADD COLUMN created_at DATETIME NULL;
+
+UPDATE usersStory SET created_at = UTC_TIMESTAMP();
+
+ALTER TABLE usersStory MODIFY COLUMN created_at DATETIME NOT NULL;
+
+CREATE INDEX usersStory_feed_newest_index ON usersStory (status, created_at, id);
+
+-- One row per user who liked a story.
+CREATE TABLE story_likes (
+    story_id   INT      NOT NULL,
+    user_id    INT      NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (story_id, user_id),
+    KEY story_likes_user_index (user_id),
+    CONSTRAINT story_likes_story_fk FOREIGN KEY (story_id) REFERENCES usersStory (id) ON DELETE CASCADE,
+    CONSTRAINT story_likes_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
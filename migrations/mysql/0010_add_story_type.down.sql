@@ -0,0 +1 @@
+ALTER TABLE usersStory DROP COLUMN story_type;
@@ -0,0 +1,3 @@
+-- Story documents are validated against the JSON Schema of their type.
+-- Stories written before types existed keep loading as legacy stories.
+ALTER TABLE usersStory ADD COLUMN story_type VARCHAR(50) NOT NULL DEFAULT 'legacy';
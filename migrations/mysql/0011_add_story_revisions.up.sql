@@ -0,0 +1,19 @@
+-- Every write to a story's document is kept as an immutable revision,
+-- numbered from 1 per story. Stories written before revisions existed get
+-- their first revision the next time they change.
+CREATE TABLE story_revisions (
+    id            INT         NOT NULL AUTO_INCREMENT,
+    story_id      INT         NOT NULL,
+    revision      INT         NOT NULL,
+    story_type    VARCHAR(50) NOT NULL,
+    stories       JSON        NOT NULL,
+    content_hash  CHAR(64)    NOT NULL,
+    author_id     INT         NULL,
+    restored_from INT         NULL,
+    created_at    DATETIME    NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY story_revisions_story_revision_unique (story_id, revision),
+    KEY story_revisions_author_index (author_id),
+    CONSTRAINT story_revisions_story_fk FOREIGN KEY (story_id) REFERENCES usersStory (id) ON DELETE CASCADE,
+    CONSTRAINT story_revisions_author_fk FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+DROP INDEX users_search_index ON users;
+
+DROP INDEX usersStory_search_index ON usersStory;
+
+ALTER TABLE usersStory DROP COLUMN search_text;
@@ -0,0 +1,8 @@
+-- The plain text of every story document, kept by the application and
+-- searched with a FULLTEXT index. Existing stories are filled by the Go
+-- step of this migration.
+ALTER TABLE usersStory ADD COLUMN search_text MEDIUMTEXT NULL;
+
+CREATE FULLTEXT INDEX usersStory_search_index ON usersStory (search_text);
+
+CREATE FULLTEXT INDEX users_search_index ON users (full_name, email);
@@ -0,0 +1,15 @@
+-- Put the tags back into the documents, ahead of the entries the up
+-- migration could not move. The Go step of this migration then records
+-- a revision for every story rewritten here.
+UPDATE usersStory s SET s.stories = JSON_SET(s.stories, '$.tags', JSON_MERGE_PRESERVE(
+    (
+        SELECT JSON_ARRAYAGG(g.name) FROM story_tags st JOIN tags g ON g.id = st.tag_id
+        WHERE st.story_id = s.id
+    ),
+    COALESCE(JSON_EXTRACT(s.stories, '$.tags'), JSON_ARRAY())
+))
+WHERE s.stories IS NOT NULL AND EXISTS (SELECT 1 FROM story_tags st WHERE st.story_id = s.id)
+    AND COALESCE(JSON_TYPE(JSON_EXTRACT(s.stories, '$.tags')), 'ARRAY') = 'ARRAY';
+
+DROP TABLE IF EXISTS story_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,64 @@
+-- Tags group stories. Names are unique regardless of case, but not of
+-- accents.
+CREATE TABLE tags (
+    id         INT         NOT NULL AUTO_INCREMENT,
+    name       VARCHAR(50) NOT NULL COLLATE utf8mb4_0900_as_ci,
+    created_at DATETIME    NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY tags_name_unique (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE story_tags (
+    story_id INT NOT NULL,
+    tag_id   INT NOT NULL,
+    PRIMARY KEY (story_id, tag_id),
+    KEY story_tags_tag_index (tag_id),
+    CONSTRAINT story_tags_story_fk FOREIGN KEY (story_id) REFERENCES usersStory (id) ON DELETE CASCADE,
+    CONSTRAINT story_tags_tag_fk FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Tags used to be kept in a "tags" array of the story documents. Move
+-- them here; the first spelling of a name wins.
+INSERT IGNORE INTO tags (name, created_at)
+SELECT TRIM(t.name), UTC_TIMESTAMP()
+FROM usersStory s CROSS JOIN JSON_TABLE(s.stories, '$.tags[*]' COLUMNS (
+    position FOR ORDINALITY,
+    item     JSON         PATH '$',
+    name     VARCHAR(255) PATH '$'
+)) t
+WHERE JSON_TYPE(JSON_EXTRACT(s.stories, '$.tags')) = 'ARRAY' AND JSON_TYPE(t.item) = 'STRING'
+    AND CHAR_LENGTH(TRIM(t.name)) BETWEEN 1 AND 50
+ORDER BY s.id, t.position;
+
+INSERT IGNORE INTO story_tags (story_id, tag_id)
+SELECT s.id, g.id
+FROM usersStory s CROSS JOIN JSON_TABLE(s.stories, '$.tags[*]' COLUMNS (
+    item JSON         PATH '$',
+    name VARCHAR(255) PATH '$'
+)) t
+JOIN tags g ON g.name = TRIM(t.name)
+WHERE JSON_TYPE(JSON_EXTRACT(s.stories, '$.tags')) = 'ARRAY' AND JSON_TYPE(t.item) = 'STRING';
+
+-- Drop the moved entries from the documents. Entries that could not be
+-- moved, such as numbers or names that are too long, stay in the array
+-- for the authors to deal with; the array goes once nothing is left in
+-- it. The Go step of this migration then records a revision for every
+-- story rewritten here.
+UPDATE usersStory s SET s.stories = IF(
+    EXISTS (
+        SELECT 1 FROM JSON_TABLE(s.stories, '$.tags[*]' COLUMNS (
+            item JSON         PATH '$',
+            name VARCHAR(255) PATH '$' NULL ON ERROR
+        )) t
+        WHERE NOT COALESCE(JSON_TYPE(t.item) = 'STRING' AND CHAR_LENGTH(TRIM(t.name)) BETWEEN 1 AND 50, FALSE)
+    ),
+    JSON_SET(s.stories, '$.tags', (
+        SELECT JSON_ARRAYAGG(t.item) FROM JSON_TABLE(s.stories, '$.tags[*]' COLUMNS (
+            item JSON         PATH '$',
+            name VARCHAR(255) PATH '$' NULL ON ERROR
+        )) t
+        WHERE NOT COALESCE(JSON_TYPE(t.item) = 'STRING' AND CHAR_LENGTH(TRIM(t.name)) BETWEEN 1 AND 50, FALSE)
+    )),
+    JSON_REMOVE(s.stories, '$.tags')
+)
+WHERE JSON_TYPE(JSON_EXTRACT(s.stories, '$.tags')) = 'ARRAY';
@@ -0,0 +1,21 @@
+-- Comments on stories, and replies to them when parent_id is set. Deleted
+-- comments keep their row, without their text, so their replies keep
+-- their place; hidden ones are only taken out of view.
+CREATE TABLE story_comments (
+    id         INT      NOT NULL AUTO_INCREMENT,
+    story_id   INT      NOT NULL,
+    parent_id  INT      NULL,
+    user_id    INT      NULL,
+    body       TEXT     NOT NULL,
+    created_at DATETIME NOT NULL,
+    edited_at  DATETIME NULL,
+    deleted_at DATETIME NULL,
+    hidden_at  DATETIME NULL,
+    PRIMARY KEY (id),
+    KEY story_comments_story_index (story_id, parent_id, id),
+    KEY story_comments_parent_index (parent_id),
+    KEY story_comments_user_index (user_id),
+    CONSTRAINT story_comments_story_fk FOREIGN KEY (story_id) REFERENCES usersStory (id) ON DELETE CASCADE,
+    CONSTRAINT story_comments_parent_fk FOREIGN KEY (parent_id) REFERENCES story_comments (id) ON DELETE CASCADE,
+    CONSTRAINT story_comments_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+-- MySQL stores DATETIME values natively; only SQLite needed its times
+-- rewritten. The version exists so both drivers stay in step.
@@ -0,0 +1 @@
+ALTER TABLE user_token_revocations MODIFY revoked_before DATETIME NOT NULL;
@@ -0,0 +1,3 @@
+-- Access tokens carry their issue time in microseconds, so the cut-off
+-- revoking all tokens of a user needs them too.
+ALTER TABLE user_token_revocations MODIFY revoked_before DATETIME(6) NOT NULL;
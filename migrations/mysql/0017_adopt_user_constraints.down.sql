@@ -0,0 +1,2 @@
+-- Nothing to undo: 0001 defines both constraints, this migration only
+-- adds them where a hand-made table lacks them.
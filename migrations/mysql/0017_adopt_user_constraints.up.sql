@@ -0,0 +1,4 @@
+-- Tables created by hand before 0001 kept their own definition, without
+-- the unique email index or the cascading story foreign key. The Go step
+-- of this migration adds whichever is missing, and fails naming the rows
+-- in the way if existing data violates it.
@@ -0,0 +1,19 @@
+-- SQLite version of the original tables. The stories document is kept as
+-- JSON text, which SQLite's JSON functions read directly.
+CREATE TABLE IF NOT EXISTS users (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    full_name   TEXT NOT NULL,
+    email       TEXT NOT NULL COLLATE NOCASE,
+    password    TEXT NOT NULL,
+    profile_pic TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_unique ON users (email);
+
+CREATE TABLE IF NOT EXISTS usersStory (
+    id      INTEGER PRIMARY KEY AUTOINCREMENT,
+    stories TEXT NULL CHECK (stories IS NULL OR json_valid(stories)),
+    userId  INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS usersStory_userId_index ON usersStory (userId);
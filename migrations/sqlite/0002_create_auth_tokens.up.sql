@@ -0,0 +1,28 @@
+CREATE TABLE refresh_tokens (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id  TEXT     NOT NULL,
+    token_hash TEXT     NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    used_at    DATETIME NULL,
+    revoked_at DATETIME NULL
+);
+
+CREATE INDEX refresh_tokens_family_index ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_index ON refresh_tokens (user_id);
+
+CREATE TABLE revoked_tokens (
+    jti        TEXT PRIMARY KEY,
+    user_id    INTEGER  NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_index ON revoked_tokens (expires_at);
+
+CREATE TABLE user_token_revocations (
+    user_id        INTEGER PRIMARY KEY,
+    revoked_before DATETIME NOT NULL,
+    expires_at     DATETIME NOT NULL
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_deleted_at_index;
+
+ALTER TABLE users DROP COLUMN deleted_at;
@@ -0,0 +1,10 @@
+CREATE TABLE password_reset_tokens (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash TEXT     NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    used_at    DATETIME NULL
+);
+
+CREATE INDEX password_reset_tokens_user_index ON password_reset_tokens (user_id);
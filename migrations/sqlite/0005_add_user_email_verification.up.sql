@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL;
+
+ALTER TABLE users ADD COLUMN verification_sent_at DATETIME NULL;
+
+-- Accounts registered before verification existed keep working.
+UPDATE users SET email_verified_at = CURRENT_TIMESTAMP;
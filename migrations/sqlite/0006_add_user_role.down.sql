@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_role_index;
+
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_disabled_at_index;
+
+ALTER TABLE users DROP COLUMN disabled_at;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN disabled_at DATETIME NULL;
+
+CREATE INDEX users_disabled_at_index ON users (disabled_at);
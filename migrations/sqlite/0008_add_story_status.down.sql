@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS story_status_transitions;
+
+DROP INDEX IF EXISTS usersStory_status_index;
+
+ALTER TABLE usersStory DROP COLUMN status;
@@ -0,0 +1,15 @@
+ALTER TABLE usersStory ADD COLUMN status TEXT NOT NULL DEFAULT 'draft';
+
+CREATE INDEX usersStory_status_index ON usersStory (status);
+
+CREATE TABLE story_status_transitions (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    story_id    INTEGER  NOT NULL REFERENCES usersStory (id) ON DELETE CASCADE,
+    from_status TEXT     NOT NULL,
+    to_status   TEXT     NOT NULL,
+    actor_id    INTEGER  NULL REFERENCES users (id) ON DELETE SET NULL,
+    reason      TEXT     NULL,
+    created_at  DATETIME NOT NULL
+);
+
+CREATE INDEX story_status_transitions_story_index ON story_status_transitions (story_id);
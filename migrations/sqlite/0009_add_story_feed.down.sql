@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS story_likes;
+
+DROP INDEX IF EXISTS usersStory_feed_newest_index;
+
+ALTER TABLE usersStory DROP COLUMN created_at;
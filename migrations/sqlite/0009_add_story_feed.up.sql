@@ -0,0 +1,18 @@
+-- SQLite cannot add a NOT NULL column without a constant default; the
+-- repositories always set created_at. The format matches the one the
+-- driver writes for UTC times with _time_format=sqlite, so both sort
+-- together.
+ALTER TABLE usersStory ADD COLUMN created_at DATETIME NULL;
+
+UPDATE usersStory SET created_at = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now');
+
+CREATE INDEX usersStory_feed_newest_index ON usersStory (status, created_at, id);
+
+CREATE TABLE story_likes (
+    story_id   INTEGER  NOT NULL REFERENCES usersStory (id) ON DELETE CASCADE,
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (story_id, user_id)
+);
+
+CREATE INDEX story_likes_user_index ON story_likes (user_id);
@@ -0,0 +1 @@
+ALTER TABLE usersStory ADD COLUMN story_type TEXT NOT NULL DEFAULT 'legacy';
@@ -0,0 +1,14 @@
+CREATE TABLE story_revisions (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    story_id      INTEGER  NOT NULL REFERENCES usersStory (id) ON DELETE CASCADE,
+    revision      INTEGER  NOT NULL,
+    story_type    TEXT     NOT NULL,
+    stories       TEXT     NOT NULL,
+    content_hash  TEXT     NOT NULL,
+    author_id     INTEGER  NULL REFERENCES users (id) ON DELETE SET NULL,
+    restored_from INTEGER  NULL,
+    created_at    DATETIME NOT NULL,
+    UNIQUE (story_id, revision)
+);
+
+CREATE INDEX story_revisions_author_index ON story_revisions (author_id);
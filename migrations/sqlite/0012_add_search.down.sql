@@ -0,0 +1 @@
+ALTER TABLE usersStory DROP COLUMN search_text;
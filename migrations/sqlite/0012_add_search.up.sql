@@ -0,0 +1,3 @@
+-- SQLite databases are searched through an in-process index; the column
+-- only keeps the schema the same as on MySQL.
+ALTER TABLE usersStory ADD COLUMN search_text TEXT NULL;
@@ -0,0 +1,27 @@
+-- Put the tags back into the documents, ahead of the entries the up
+-- migration could not move. The Go step of this migration then records
+-- a revision for every story rewritten here.
+UPDATE usersStory SET stories = json_set(stories, '$.tags', json((
+    SELECT '[' || group_concat(item, ',') || ']' FROM (
+        SELECT item FROM (
+            SELECT 0 AS part, g.name AS position, json_quote(g.name) AS item
+            FROM story_tags st JOIN tags g ON g.id = st.tag_id
+            WHERE st.story_id = usersStory.id
+            UNION ALL
+            SELECT 1, t.key, CASE t.type
+                WHEN 'text' THEN json_quote(t.value)
+                WHEN 'true' THEN 'true'
+                WHEN 'false' THEN 'false'
+                WHEN 'null' THEN 'null'
+                ELSE CAST(t.value AS TEXT)
+            END
+            FROM json_each(usersStory.stories, '$.tags') t
+        )
+        ORDER BY part, position
+    )
+)))
+WHERE stories IS NOT NULL AND EXISTS (SELECT 1 FROM story_tags st WHERE st.story_id = usersStory.id)
+    AND coalesce(json_type(stories, '$.tags'), 'array') = 'array';
+
+DROP TABLE IF EXISTS story_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,54 @@
+-- Tags group stories. Names are unique regardless of case.
+CREATE TABLE tags (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT     NOT NULL COLLATE NOCASE UNIQUE,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE story_tags (
+    story_id INTEGER NOT NULL REFERENCES usersStory (id) ON DELETE CASCADE,
+    tag_id   INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (story_id, tag_id)
+);
+
+CREATE INDEX story_tags_tag_index ON story_tags (tag_id);
+
+-- Tags used to be kept in a "tags" array of the story documents. Move
+-- them here; the first spelling of a name wins.
+INSERT OR IGNORE INTO tags (name, created_at)
+SELECT trim(t.value), strftime('%Y-%m-%d %H:%M:%S+00:00', 'now')
+FROM usersStory s, json_each(s.stories, '$.tags') t
+WHERE json_type(s.stories, '$.tags') = 'array' AND t.type = 'text' AND length(trim(t.value)) BETWEEN 1 AND 50
+ORDER BY s.id, t.key;
+
+INSERT OR IGNORE INTO story_tags (story_id, tag_id)
+SELECT s.id, g.id
+FROM usersStory s, json_each(s.stories, '$.tags') t JOIN tags g ON g.name = trim(t.value)
+WHERE json_type(s.stories, '$.tags') = 'array' AND t.type = 'text';
+
+-- Drop the moved entries from the documents. Entries that could not be
+-- moved, such as numbers or names that are too long, stay in the array
+-- for the authors to deal with; the array goes once nothing is left in
+-- it. The Go step of this migration then records a revision for every
+-- story rewritten here.
+UPDATE usersStory SET stories = CASE
+    WHEN EXISTS (
+        SELECT 1 FROM json_each(usersStory.stories, '$.tags') t
+        WHERE NOT (t.type = 'text' AND length(trim(t.value)) BETWEEN 1 AND 50)
+    ) THEN json_set(stories, '$.tags', json((
+        SELECT '[' || group_concat(item, ',') || ']' FROM (
+            SELECT CASE t.type
+                WHEN 'text' THEN json_quote(t.value)
+                WHEN 'true' THEN 'true'
+                WHEN 'false' THEN 'false'
+                WHEN 'null' THEN 'null'
+                ELSE CAST(t.value AS TEXT)
+            END AS item
+            FROM json_each(usersStory.stories, '$.tags') t
+            WHERE NOT (t.type = 'text' AND length(trim(t.value)) BETWEEN 1 AND 50)
+            ORDER BY t.key
+        )
+    )))
+    ELSE json_remove(stories, '$.tags')
+END
+WHERE json_type(stories, '$.tags') = 'array';
@@ -0,0 +1,15 @@
+CREATE TABLE story_comments (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    story_id   INTEGER  NOT NULL REFERENCES usersStory (id) ON DELETE CASCADE,
+    parent_id  INTEGER  NULL REFERENCES story_comments (id) ON DELETE CASCADE,
+    user_id    INTEGER  NULL REFERENCES users (id) ON DELETE SET NULL,
+    body       TEXT     NOT NULL,
+    created_at DATETIME NOT NULL,
+    edited_at  DATETIME NULL,
+    deleted_at DATETIME NULL,
+    hidden_at  DATETIME NULL
+);
+
+CREATE INDEX story_comments_story_index ON story_comments (story_id, parent_id, id);
+CREATE INDEX story_comments_parent_index ON story_comments (parent_id);
+CREATE INDEX story_comments_user_index ON story_comments (user_id);
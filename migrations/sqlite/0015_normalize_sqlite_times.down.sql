@@ -0,0 +1,50 @@
+-- Go back to the format time.Time.String writes.
+
+UPDATE users SET
+    email_verified_at = replace(email_verified_at, '+00:00', ' +0000 UTC'),
+    verification_sent_at = replace(verification_sent_at, '+00:00', ' +0000 UTC'),
+    deleted_at = replace(deleted_at, '+00:00', ' +0000 UTC'),
+    disabled_at = replace(disabled_at, '+00:00', ' +0000 UTC');
+
+UPDATE usersStory SET
+    created_at = replace(created_at, '+00:00', ' +0000 UTC');
+
+UPDATE refresh_tokens SET
+    expires_at = replace(expires_at, '+00:00', ' +0000 UTC'),
+    created_at = replace(created_at, '+00:00', ' +0000 UTC'),
+    used_at = replace(used_at, '+00:00', ' +0000 UTC'),
+    revoked_at = replace(revoked_at, '+00:00', ' +0000 UTC');
+
+UPDATE revoked_tokens SET
+    expires_at = replace(expires_at, '+00:00', ' +0000 UTC'),
+    revoked_at = replace(revoked_at, '+00:00', ' +0000 UTC');
+
+UPDATE user_token_revocations SET
+    revoked_before = replace(revoked_before, '+00:00', ' +0000 UTC'),
+    expires_at = replace(expires_at, '+00:00', ' +0000 UTC');
+
+UPDATE password_reset_tokens SET
+    expires_at = replace(expires_at, '+00:00', ' +0000 UTC'),
+    created_at = replace(created_at, '+00:00', ' +0000 UTC'),
+    used_at = replace(used_at, '+00:00', ' +0000 UTC');
+
+UPDATE story_status_transitions SET
+    created_at = replace(created_at, '+00:00', ' +0000 UTC');
+
+UPDATE story_likes SET
+    created_at = replace(created_at, '+00:00', ' +0000 UTC');
+
+UPDATE story_revisions SET
+    created_at = replace(created_at, '+00:00', ' +0000 UTC');
+
+UPDATE tags SET
+    created_at = replace(created_at, '+00:00', ' +0000 UTC');
+
+UPDATE story_comments SET
+    created_at = replace(created_at, '+00:00', ' +0000 UTC'),
+    edited_at = replace(edited_at, '+00:00', ' +0000 UTC'),
+    deleted_at = replace(deleted_at, '+00:00', ' +0000 UTC'),
+    hidden_at = replace(hidden_at, '+00:00', ' +0000 UTC');
+
+UPDATE schema_migrations SET
+    applied_at = replace(applied_at, '+00:00', ' +0000 UTC');
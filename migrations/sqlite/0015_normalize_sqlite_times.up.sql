@@ -0,0 +1,179 @@
+-- Times used to be written by the driver with time.Time.String
+-- ("2006-01-02 15:04:05 +0000 UTC") and by earlier migrations as
+-- "2006-01-02T15:04:05Z" or "2006-01-02 15:04:05", which do not sort
+-- together as text. Rewrite them all in the format the driver now
+-- writes, "2006-01-02 15:04:05+00:00". Every time is stored in UTC.
+
+UPDATE users SET
+    email_verified_at = CASE
+        WHEN email_verified_at LIKE '% +0000 UTC' THEN replace(email_verified_at, ' +0000 UTC', '+00:00')
+        WHEN email_verified_at LIKE '____-__-__T%Z' THEN replace(substr(email_verified_at, 1, length(email_verified_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(email_verified_at) = 19 THEN email_verified_at || '+00:00'
+        ELSE email_verified_at
+    END,
+    verification_sent_at = CASE
+        WHEN verification_sent_at LIKE '% +0000 UTC' THEN replace(verification_sent_at, ' +0000 UTC', '+00:00')
+        WHEN verification_sent_at LIKE '____-__-__T%Z' THEN replace(substr(verification_sent_at, 1, length(verification_sent_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(verification_sent_at) = 19 THEN verification_sent_at || '+00:00'
+        ELSE verification_sent_at
+    END,
+    deleted_at = CASE
+        WHEN deleted_at LIKE '% +0000 UTC' THEN replace(deleted_at, ' +0000 UTC', '+00:00')
+        WHEN deleted_at LIKE '____-__-__T%Z' THEN replace(substr(deleted_at, 1, length(deleted_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(deleted_at) = 19 THEN deleted_at || '+00:00'
+        ELSE deleted_at
+    END,
+    disabled_at = CASE
+        WHEN disabled_at LIKE '% +0000 UTC' THEN replace(disabled_at, ' +0000 UTC', '+00:00')
+        WHEN disabled_at LIKE '____-__-__T%Z' THEN replace(substr(disabled_at, 1, length(disabled_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(disabled_at) = 19 THEN disabled_at || '+00:00'
+        ELSE disabled_at
+    END;
+
+UPDATE usersStory SET
+    created_at = CASE
+        WHEN created_at LIKE '% +0000 UTC' THEN replace(created_at, ' +0000 UTC', '+00:00')
+        WHEN created_at LIKE '____-__-__T%Z' THEN replace(substr(created_at, 1, length(created_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(created_at) = 19 THEN created_at || '+00:00'
+        ELSE created_at
+    END;
+
+UPDATE refresh_tokens SET
+    expires_at = CASE
+        WHEN expires_at LIKE '% +0000 UTC' THEN replace(expires_at, ' +0000 UTC', '+00:00')
+        WHEN expires_at LIKE '____-__-__T%Z' THEN replace(substr(expires_at, 1, length(expires_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(expires_at) = 19 THEN expires_at || '+00:00'
+        ELSE expires_at
+    END,
+    created_at = CASE
+        WHEN created_at LIKE '% +0000 UTC' THEN replace(created_at, ' +0000 UTC', '+00:00')
+        WHEN created_at LIKE '____-__-__T%Z' THEN replace(substr(created_at, 1, length(created_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(created_at) = 19 THEN created_at || '+00:00'
+        ELSE created_at
+    END,
+    used_at = CASE
+        WHEN used_at LIKE '% +0000 UTC' THEN replace(used_at, ' +0000 UTC', '+00:00')
+        WHEN used_at LIKE '____-__-__T%Z' THEN replace(substr(used_at, 1, length(used_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(used_at) = 19 THEN used_at || '+00:00'
+        ELSE used_at
+    END,
+    revoked_at = CASE
+        WHEN revoked_at LIKE '% +0000 UTC' THEN replace(revoked_at, ' +0000 UTC', '+00:00')
+        WHEN revoked_at LIKE '____-__-__T%Z' THEN replace(substr(revoked_at, 1, length(revoked_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(revoked_at) = 19 THEN revoked_at || '+00:00'
+        ELSE revoked_at
+    END;
+
+UPDATE revoked_tokens SET
+    expires_at = CASE
+        WHEN expires_at LIKE '% +0000 UTC' THEN replace(expires_at, ' +0000 UTC', '+00:00')
+        WHEN expires_at LIKE '____-__-__T%Z' THEN replace(substr(expires_at, 1, length(expires_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(expires_at) = 19 THEN expires_at || '+00:00'
+        ELSE expires_at
+    END,
+    revoked_at = CASE
+        WHEN revoked_at LIKE '% +0000 UTC' THEN replace(revoked_at, ' +0000 UTC', '+00:00')
+        WHEN revoked_at LIKE '____-__-__T%Z' THEN replace(substr(revoked_at, 1, length(revoked_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(revoked_at) = 19 THEN revoked_at || '+00:00'
+        ELSE revoked_at
+    END;
+
+UPDATE user_token_revocations SET
+    revoked_before = CASE
+        WHEN revoked_before LIKE '% +0000 UTC' THEN replace(revoked_before, ' +0000 UTC', '+00:00')
+        WHEN revoked_before LIKE '____-__-__T%Z' THEN replace(substr(revoked_before, 1, length(revoked_before) - 1), 'T', ' ') || '+00:00'
+        WHEN length(revoked_before) = 19 THEN revoked_before || '+00:00'
+        ELSE revoked_before
+    END,
+    expires_at = CASE
+        WHEN expires_at LIKE '% +0000 UTC' THEN replace(expires_at, ' +0000 UTC', '+00:00')
+        WHEN expires_at LIKE '____-__-__T%Z' THEN replace(substr(expires_at, 1, length(expires_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(expires_at) = 19 THEN expires_at || '+00:00'
+        ELSE expires_at
+    END;
+
+UPDATE password_reset_tokens SET
+    expires_at = CASE
+        WHEN expires_at LIKE '% +0000 UTC' THEN replace(expires_at, ' +0000 UTC', '+00:00')
+        WHEN expires_at LIKE '____-__-__T%Z' THEN replace(substr(expires_at, 1, length(expires_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(expires_at) = 19 THEN expires_at || '+00:00'
+        ELSE expires_at
+    END,
+    created_at = CASE
+        WHEN created_at LIKE '% +0000 UTC' THEN replace(created_at, ' +0000 UTC', '+00:00')
+        WHEN created_at LIKE '____-__-__T%Z' THEN replace(substr(created_at, 1, length(created_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(created_at) = 19 THEN created_at || '+00:00'
+        ELSE created_at
+    END,
+    used_at = CASE
+        WHEN used_at LIKE '% +0000 UTC' THEN replace(used_at, ' +0000 UTC', '+00:00')
+        WHEN used_at LIKE '____-__-__T%Z' THEN replace(substr(used_at, 1, length(used_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(used_at) = 19 THEN used_at || '+00:00'
+        ELSE used_at
+    END;
+
+UPDATE story_status_transitions SET
+    created_at = CASE
+        WHEN created_at LIKE '% +0000 UTC' THEN replace(created_at, ' +0000 UTC', '+00:00')
+        WHEN created_at LIKE '____-__-__T%Z' THEN replace(substr(created_at, 1, length(created_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(created_at) = 19 THEN created_at || '+00:00'
+        ELSE created_at
+    END;
+
+UPDATE story_likes SET
+    created_at = CASE
+        WHEN created_at LIKE '% +0000 UTC' THEN replace(created_at, ' +0000 UTC', '+00:00')
+        WHEN created_at LIKE '____-__-__T%Z' THEN replace(substr(created_at, 1, length(created_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(created_at) = 19 THEN created_at || '+00:00'
+        ELSE created_at
+    END;
+
+UPDATE story_revisions SET
+    created_at = CASE
+        WHEN created_at LIKE '% +0000 UTC' THEN replace(created_at, ' +0000 UTC', '+00:00')
+        WHEN created_at LIKE '____-__-__T%Z' THEN replace(substr(created_at, 1, length(created_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(created_at) = 19 THEN created_at || '+00:00'
+        ELSE created_at
+    END;
+
+UPDATE tags SET
+    created_at = CASE
+        WHEN created_at LIKE '% +0000 UTC' THEN replace(created_at, ' +0000 UTC', '+00:00')
+        WHEN created_at LIKE '____-__-__T%Z' THEN replace(substr(created_at, 1, length(created_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(created_at) = 19 THEN created_at || '+00:00'
+        ELSE created_at
+    END;
+
+UPDATE story_comments SET
+    created_at = CASE
+        WHEN created_at LIKE '% +0000 UTC' THEN replace(created_at, ' +0000 UTC', '+00:00')
+        WHEN created_at LIKE '____-__-__T%Z' THEN replace(substr(created_at, 1, length(created_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(created_at) = 19 THEN created_at || '+00:00'
+        ELSE created_at
+    END,
+    edited_at = CASE
+        WHEN edited_at LIKE '% +0000 UTC' THEN replace(edited_at, ' +0000 UTC', '+00:00')
+        WHEN edited_at LIKE '____-__-__T%Z' THEN replace(substr(edited_at, 1, length(edited_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(edited_at) = 19 THEN edited_at || '+00:00'
+        ELSE edited_at
+    END,
+    deleted_at = CASE
+        WHEN deleted_at LIKE '% +0000 UTC' THEN replace(deleted_at, ' +0000 UTC', '+00:00')
+        WHEN deleted_at LIKE '____-__-__T%Z' THEN replace(substr(deleted_at, 1, length(deleted_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(deleted_at) = 19 THEN deleted_at || '+00:00'
+        ELSE deleted_at
+    END,
+    hidden_at = CASE
+        WHEN hidden_at LIKE '% +0000 UTC' THEN replace(hidden_at, ' +0000 UTC', '+00:00')
+        WHEN hidden_at LIKE '____-__-__T%Z' THEN replace(substr(hidden_at, 1, length(hidden_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(hidden_at) = 19 THEN hidden_at || '+00:00'
+        ELSE hidden_at
+    END;
+
+UPDATE schema_migrations SET
+    applied_at = CASE
+        WHEN applied_at LIKE '% +0000 UTC' THEN replace(applied_at, ' +0000 UTC', '+00:00')
+        WHEN applied_at LIKE '____-__-__T%Z' THEN replace(substr(applied_at, 1, length(applied_at) - 1), 'T', ' ') || '+00:00'
+        WHEN length(applied_at) = 19 THEN applied_at || '+00:00'
+        ELSE applied_at
+    END;
@@ -0,0 +1,2 @@
+-- SQLite keeps the fractional seconds of times already; the version
+-- exists so both drivers stay in step.
@@ -0,0 +1,2 @@
+-- SQLite tables were always created by 0001 with both constraints; the
+-- version exists so both drivers stay in step.
@@ -0,0 +1,297 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"blog_project.com/search"
+)
+
+// steps are the Go steps of migrations, by driver and version.
+var steps = map[string]map[int]func(q execer) error{
+	"mysql": {
+		12: fillSearchText,
+		13: recordStoryRevisions,
+		17: adoptUserConstraints,
+	},
+	"sqlite": {
+		13: recordStoryRevisions,
+	},
+}
+
+// searchBatchSize is how many stories fillSearchText reads at a time.
+const searchBatchSize = 500
+
+// fillSearchText fills search_text for the stories written before the
+// column existed, the way the searcher's Reindex does, so they can be
+// found right after migrating. It does nothing once the down script has
+// dropped the column.
+func fillSearchText(q execer) error {
+	var columns int
+	err := queryRow(q, &columns, `SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'usersStory' AND COLUMN_NAME = 'search_text'`)
+	if err != nil || columns == 0 {
+		return err
+	}
+
+	lastID := 0
+	for {
+		rows, err := q.Query(
+			"SELECT id, stories FROM usersStory WHERE id > ? AND stories IS NOT NULL AND search_text IS NULL ORDER BY id LIMIT ?",
+			lastID, searchBatchSize,
+		)
+		if err != nil {
+			return err
+		}
+		texts := map[int]string{}
+		var ids []int
+		for rows.Next() {
+			var id int
+			var data string
+			if err := rows.Scan(&id, &data); err != nil {
+				rows.Close()
+				return err
+			}
+			content := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(data), &content); err != nil {
+				rows.Close()
+				return fmt.Errorf("story %d: %v", id, err)
+			}
+			texts[id] = search.DocumentText(content)
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return err
+		}
+		// MySQL cannot run statements while the rows are still being read
+		rows.Close()
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, id := range ids {
+			if _, err := q.Exec("UPDATE usersStory SET search_text = ? WHERE id = ?", texts[id], id); err != nil {
+				return err
+			}
+		}
+		lastID = ids[len(ids)-1]
+	}
+}
+
+// recordStoryRevisions records a revision for every story whose document
+// no longer matches its latest revision, after a migration rewrote
+// documents: every write to a story is kept as a revision. Stories
+// without revisions are left alone, they get a first one the next time
+// they change.
+//
+// Revisions are stored the way the story repository stores them: the
+// document is the encoding/json encoding of it, which writes object
+// members in key order, and the content hash is the hex SHA-256 of that.
+func recordStoryRevisions(q execer) error {
+	rows, err := q.Query(`SELECT s.id, s.story_type, s.stories, r.revision, r.stories
+		FROM usersStory s JOIN story_revisions r ON r.story_id = s.id
+		WHERE s.stories IS NOT NULL AND r.revision = (SELECT MAX(revision) FROM story_revisions WHERE story_id = s.id)`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type revision struct {
+		storyID, revision int
+		storyType         string
+		document          []byte
+	}
+	var changed []revision
+	for rows.Next() {
+		var rev revision
+		var current, latest string
+		if err := rows.Scan(&rev.storyID, &rev.storyType, &current, &rev.revision, &latest); err != nil {
+			return err
+		}
+		var currentContent, latestContent map[string]interface{}
+		if err := json.Unmarshal([]byte(current), &currentContent); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(latest), &latestContent); err != nil {
+			return err
+		}
+		if currentContent == nil {
+			currentContent = map[string]interface{}{}
+		}
+		if latestContent == nil {
+			latestContent = map[string]interface{}{}
+		}
+		if reflect.DeepEqual(currentContent, latestContent) {
+			continue
+		}
+		if rev.document, err = json.Marshal(currentContent); err != nil {
+			return err
+		}
+		changed = append(changed, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// MySQL cannot run statements while the rows are still being read
+	rows.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, rev := range changed {
+		sum := sha256.Sum256(rev.document)
+		_, err := q.Exec(
+			"INSERT INTO story_revisions (story_id, revision, story_type, stories, content_hash, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			rev.storyID, rev.revision+1, rev.storyType, string(rev.document), hex.EncodeToString(sum[:]), now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adoptUserConstraints adds the unique email index and the cascading
+// story foreign key of 0001 to users and usersStory tables that were
+// created by hand before the migrator, where CREATE TABLE IF NOT EXISTS
+// left them as they were. Accounts are told apart by email and deleted
+// with their stories only with both in place.
+//
+// Rows that violate a constraint are not touched: the step fails and
+// names them, so they can be cleaned up before migrating again.
+func adoptUserConstraints(q execer) error {
+	if err := addEmailIndex(q); err != nil {
+		return err
+	}
+	return addStoryUserForeignKey(q)
+}
+
+// addEmailIndex adds users_email_unique unless a unique index on the
+// email column alone exists.
+func addEmailIndex(q execer) error {
+	var indexed int
+	err := queryRow(q, &indexed, `SELECT COUNT(*) FROM (
+		SELECT INDEX_NAME FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'users'
+		GROUP BY INDEX_NAME
+		HAVING COUNT(*) = 1 AND MAX(COLUMN_NAME) = 'email' AND MAX(NON_UNIQUE) = 0
+	) email_indexes`)
+	if err != nil || indexed > 0 {
+		return err
+	}
+
+	duplicates, err := queryStrings(q, `SELECT email FROM users GROUP BY email HAVING COUNT(*) > 1 ORDER BY email LIMIT 10`)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("users cannot get a unique email index, these emails belong to more than one account: %s", strings.Join(duplicates, ", "))
+	}
+	_, err = q.Exec("ALTER TABLE users ADD UNIQUE KEY users_email_unique (email)")
+	return err
+}
+
+// addStoryUserForeignKey makes usersStory.userId reference users with
+// ON DELETE CASCADE, replacing a foreign key on it that does not cascade.
+func addStoryUserForeignKey(q execer) error {
+	engines, err := queryStrings(q, `SELECT CONCAT(TABLE_NAME, ' uses ', ENGINE) FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME IN ('users', 'usersStory') AND ENGINE <> 'InnoDB'`)
+	if err != nil {
+		return err
+	}
+	if len(engines) > 0 {
+		return fmt.Errorf("usersStory cannot reference users, foreign keys need InnoDB tables but %s", strings.Join(engines, " and "))
+	}
+
+	rows, err := q.Query(`SELECT rc.CONSTRAINT_NAME, rc.DELETE_RULE
+		FROM information_schema.REFERENTIAL_CONSTRAINTS rc
+		JOIN information_schema.KEY_COLUMN_USAGE k
+			ON k.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND k.CONSTRAINT_NAME = rc.CONSTRAINT_NAME AND k.TABLE_NAME = rc.TABLE_NAME
+		WHERE rc.CONSTRAINT_SCHEMA = DATABASE() AND rc.TABLE_NAME = 'usersStory'
+			AND rc.REFERENCED_TABLE_NAME = 'users' AND k.COLUMN_NAME = 'userId'`)
+	if err != nil {
+		return err
+	}
+	var nonCascading []string
+	cascades := false
+	for rows.Next() {
+		var name, deleteRule string
+		if err := rows.Scan(&name, &deleteRule); err != nil {
+			rows.Close()
+			return err
+		}
+		if deleteRule == "CASCADE" {
+			cascades = true
+		} else {
+			nonCascading = append(nonCascading, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	// MySQL cannot run statements while the rows are still being read
+	rows.Close()
+	if cascades {
+		return nil
+	}
+
+	orphans, err := queryStrings(q, `SELECT CAST(s.id AS CHAR) FROM usersStory s
+		LEFT JOIN users u ON u.id = s.userId
+		WHERE u.id IS NULL ORDER BY s.id LIMIT 10`)
+	if err != nil {
+		return err
+	}
+	if len(orphans) > 0 {
+		return fmt.Errorf("usersStory cannot reference users, these stories belong to no account: %s", strings.Join(orphans, ", "))
+	}
+	for _, name := range nonCascading {
+		if _, err := q.Exec("ALTER TABLE usersStory DROP FOREIGN KEY `" + name + "`"); err != nil {
+			return err
+		}
+	}
+	_, err = q.Exec("ALTER TABLE usersStory ADD CONSTRAINT usersStory_userId_fk FOREIGN KEY (userId) REFERENCES users (id) ON DELETE CASCADE")
+	return err
+}
+
+// queryRow scans the single value the query returns into dest.
+func queryRow(q execer, dest interface{}, query string) error {
+	rows, err := q.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("migrations: %q returned no rows", query)
+	}
+	if err := rows.Scan(dest); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// queryStrings returns the single string column of every row the query
+// returns.
+func queryStrings(q execer, query string) ([]string, error) {
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
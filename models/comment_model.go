@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// MaxCommentLen is the longest a comment can be, in characters.
+const MaxCommentLen = 2000
+
+// Comment is a comment on a story, or a reply to one when ParentID is
+// set. Replies cannot be replied to.
+type Comment struct {
+	ID       int
+	StoryID  int
+	ParentID int
+	// UserID is the author of the comment; it is 0 once the author has
+	// been deleted.
+	UserID    int
+	Body      string
+	CreatedAt time.Time
+	EditedAt  *time.Time
+	// DeletedAt is set when the author deleted the comment, which also
+	// erases its body; HiddenAt when a moderator hid it.
+	DeletedAt *time.Time
+	HiddenAt  *time.Time
+
+	AuthorName       string
+	AuthorProfilePic string
+	// Replies are only read for top-level comments.
+	Replies []Comment
+}
+
+// Removed reports whether the comment was deleted or hidden.
+func (c Comment) Removed() bool {
+	return c.DeletedAt != nil || c.HiddenAt != nil
+}
+
+// AddCommentRequest is the body accepted by
+// POST /api/stories/{id}/comments. ParentID is the comment replied to,
+// if any.
+type AddCommentRequest struct {
+	Body     string `json:"body"`
+	ParentID int    `json:"parent_id"`
+}
+
+// UpdateCommentRequest is the body accepted by PATCH /api/comments/{id}.
+type UpdateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// CommentModel is a comment as returned by the API. Deleted and hidden
+// comments are only shown when replies to them are, without their body
+// or author.
+type CommentModel struct {
+	ID        int               `json:"id"`
+	StoryID   int               `json:"story_id"`
+	ParentID  int               `json:"parent_id,omitempty"`
+	Author    *StoryAuthorModel `json:"author,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Deleted   bool              `json:"deleted,omitempty"`
+	Hidden    bool              `json:"hidden,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	EditedAt  *time.Time        `json:"edited_at,omitempty"`
+	Replies   []CommentModel    `json:"replies,omitempty"`
+}
+
+// CommentListModel is a page of the comments on a story returned by
+// GET /api/stories/{id}/comments. Total counts the top-level comments.
+type CommentListModel struct {
+	Comments []CommentModel `json:"comments"`
+	Page     int            `json:"page"`
+	PerPage  int            `json:"per_page"`
+	Total    int            `json:"total"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// BuildInfo describes the running binary, as reported by /version.
+type BuildInfo struct {
+	Module     string `json:"module"`
+	Version    string `json:"version"`
+	GoVersion  string `json:"go_version"`
+	Revision   string `json:"revision,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified"`
+}
@@ -10,4 +10,8 @@ type LoginResponse struct {
     FullName   string `json:"full_name"`
     Email      string `json:"email"`
     ProfilePic string `json:"profile_pic"`
+    // ProfilePicVariants maps thumbnail edge lengths to their URLs.
+    ProfilePicVariants map[string]string `json:"profile_pic_variants,omitempty"`
+    EmailVerified      bool              `json:"email_verified"`
+    Role               string            `json:"role"`
 }
\ No newline at end of file
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Principal is the authenticated identity attached to a request once its
+// bearer token has been validated.
+type Principal struct {
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	Roles     []string  `json:"roles"`
+	TokenID   string    `json:"token_id"`
+	SessionID string    `json:"session_id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HasRole reports whether the principal was granted the given role.
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether any role of the principal grants permission.
+func (p Principal) Can(permission Permission) bool {
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
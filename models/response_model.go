@@ -1,7 +1,9 @@
 package models
+
 type Response struct {
-	Status  bool        `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
-	Token   string      `json:"token,omitempty"`
-}
\ No newline at end of file
+	Status       bool        `json:"status"`
+	Message      string      `json:"message"`
+	Data         interface{} `json:"data"`
+	Token        string      `json:"token,omitempty"`
+	RefreshToken string      `json:"refresh_token,omitempty"`
+}
@@ -0,0 +1,64 @@
+package models
+
+// Roles a user can be given. Every account starts as RoleUser; editors
+// look after the content of other users and admins additionally manage
+// the accounts themselves.
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Roles lists every role, from the least to the most privileged.
+var Roles = []string{RoleUser, RoleEditor, RoleAdmin}
+
+// Permission names an action that only some roles may perform.
+type Permission string
+
+// Permissions checked by the API.
+const (
+	// PermissionManageStories allows editing and deleting the stories of
+	// other users.
+	PermissionManageStories Permission = "stories:manage"
+	// PermissionModerateStories allows approving and rejecting stories
+	// submitted for review.
+	PermissionModerateStories Permission = "stories:moderate"
+	// PermissionManageUsers allows administering user accounts and roles.
+	PermissionManageUsers Permission = "users:manage"
+	// PermissionManageTags allows renaming and merging the tags stories
+	// are grouped by.
+	PermissionManageTags Permission = "tags:manage"
+	// PermissionModerateComments allows hiding the comments of other
+	// users.
+	PermissionModerateComments Permission = "comments:moderate"
+)
+
+// rolePermissions maps every role to the permissions it grants.
+var rolePermissions = map[string][]Permission{
+	RoleUser:   nil,
+	RoleEditor: {PermissionManageStories, PermissionModerateStories},
+	RoleAdmin: {
+		PermissionManageStories,
+		PermissionModerateStories,
+		PermissionManageUsers,
+		PermissionManageTags,
+		PermissionModerateComments,
+	},
+}
+
+// ValidRole reports whether role is one of Roles.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions returns the permissions granted by role.
+func RolePermissions(role string) []Permission {
+	return append([]Permission{}, rolePermissions[role]...)
+}
+
+// RoleModel describes a role and the permissions it grants.
+type RoleModel struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// StorySearchHit is a published story found by a search, with how well
+// it matches.
+type StorySearchHit struct {
+	Story PublicStory
+	Score float64
+}
+
+// UserSearchHit is an account found by a search, with how well it
+// matches.
+type UserSearchHit struct {
+	User  RegisterUserModel
+	Score float64
+}
+
+// StorySearchResultModel is a story as returned by GET /api/search.
+// Snippet is HTML: an escaped excerpt of the story with the searched
+// words wrapped in <mark>.
+type StorySearchResultModel struct {
+	ID        int              `json:"id"`
+	Type      string           `json:"type"`
+	Author    StoryAuthorModel `json:"author"`
+	Snippet   string           `json:"snippet"`
+	Score     float64          `json:"score"`
+	Likes     int              `json:"likes"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// UserSearchResultModel is an account as returned by GET /api/search to
+// admins. Snippet highlights the name and email like
+// StorySearchResultModel.Snippet.
+type UserSearchResultModel struct {
+	ID         int     `json:"id"`
+	FullName   string  `json:"full_name"`
+	Email      string  `json:"email"`
+	ProfilePic string  `json:"profile_pic"`
+	Role       string  `json:"role"`
+	Snippet    string  `json:"snippet"`
+	Score      float64 `json:"score"`
+}
+
+// SearchResultsModel holds the results of a search, best match first.
+// Users is only returned to callers allowed to manage accounts.
+type SearchResultsModel struct {
+	Query   string                   `json:"query"`
+	Stories []StorySearchResultModel `json:"stories"`
+	Users   []UserSearchResultModel  `json:"users,omitempty"`
+}
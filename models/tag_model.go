@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Limits on the tags of a story.
+const (
+	MaxStoryTags  = 10
+	MaxTagNameLen = 50
+)
+
+// Tag groups stories, such as the "Customer success" or "Engineering"
+// sections of the website. Names are unique regardless of case.
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Stories is how many stories carry the tag; which stories count
+	// depends on who lists the tags.
+	Stories   int       `json:"stories"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RenameTagRequest is the body accepted by PUT /api/admin/tags/{id}.
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+// MergeTagRequest is the body accepted by
+// POST /api/admin/tags/{id}/merge. Into is the ID of the tag the stories
+// move to.
+type MergeTagRequest struct {
+	Into int `json:"into"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// RefreshTokenRequest is the body accepted by the token refresh endpoint.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken is a stored refresh token. Only the hash of the opaque
+// token handed to the client is ever persisted.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// Spent reports whether the token was already exchanged or revoked.
+func (t RefreshToken) Spent() bool {
+	return t.UsedAt != nil || t.RevokedAt != nil
+}
+
+// RevokedAccessTokens is a snapshot of the access token revocation list.
+type RevokedAccessTokens struct {
+	// Tokens maps revoked token IDs to the time the token expires.
+	Tokens map[string]time.Time
+	// Users maps user IDs to a cut-off: tokens issued before it are revoked.
+	Users map[int]time.Time
+}
+
+// PasswordResetToken is a stored password reset token. Only the hash of
+// the token mailed to the user is persisted.
+type PasswordResetToken struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
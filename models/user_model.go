@@ -1,15 +1,81 @@
 package models
 
+import "time"
+
 type RegisterUserModel struct{
 	ID int `json:"id"`;
 	FullName string `json:"full_name"`;
 	Email string `json:"email"`;
 	ProfilePic string `json:"profile_pic"`;
 	Password string `json:"password"`;
+	// DeletedAt is set while the account waits to be purged.
+	DeletedAt *time.Time `json:"-"`;
+	// EmailVerifiedAt is set once the owner followed a verification link.
+	EmailVerifiedAt *time.Time `json:"-"`;
+	// Role is one of Roles; it can only be changed by an admin.
+	Role string `json:"-"`;
+	// DisabledAt is set while an admin has disabled the account.
+	DisabledAt *time.Time `json:"-"`;
 }
 
 type GetUserProfileModel struct{
 	FullName string `json:"full_name"`;
 	Email string `json:"email"`;
 	ProfilePic string `json:"profile_pic"`;
-}
\ No newline at end of file
+	// ProfilePicVariants maps thumbnail edge lengths to their URLs.
+	ProfilePicVariants map[string]string `json:"profile_pic_variants,omitempty"`;
+	EmailVerified bool `json:"email_verified"`;
+	Role string `json:"role"`;
+}
+
+// UpdateProfileRequest is the body accepted by PATCH /api/profile. Only
+// the fields present are changed; changing the email also requires the
+// current password.
+type UpdateProfileRequest struct {
+	FullName        *string `json:"full_name"`
+	Email           *string `json:"email"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// DeleteAccountRequest is the body accepted by DELETE /api/profile.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// AccountDeletionModel describes a scheduled account deletion.
+type AccountDeletionModel struct {
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// ResendVerificationRequest is the body accepted by
+// POST /api/verify-email/resend.
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// AdminUserModel is an account as shown to admins.
+type AdminUserModel struct {
+	ID                  int        `json:"id"`
+	FullName            string     `json:"full_name"`
+	Email               string     `json:"email"`
+	ProfilePic          string     `json:"profile_pic"`
+	Role                string     `json:"role"`
+	EmailVerified       bool       `json:"email_verified"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at"`
+	// StoryCount is only filled in when a single user is shown.
+	StoryCount *int `json:"story_count,omitempty"`
+}
+
+// UserListModel is a page of accounts returned by GET /api/admin/users.
+type UserListModel struct {
+	Users   []AdminUserModel `json:"users"`
+	Page    int              `json:"page"`
+	PerPage int              `json:"per_page"`
+	Total   int              `json:"total"`
+}
+
+// UpdateRoleRequest is the body accepted by PUT /api/admin/users/{id}/role.
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
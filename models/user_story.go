@@ -1,10 +1,237 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type AddStoryRequest struct {
-    Story map[string]interface{} `json:"story"`
+	// Type names the story type whose schema Story must match. It is
+	// mandatory when adding a story; updates keep the current type when
+	// it is empty.
+	Type  string                 `json:"type"`
+	Story map[string]interface{} `json:"story"`
+	// Tags names the tags of the story, which are created as needed.
+	// Updates keep the current tags when it is left out.
+	Tags []string `json:"tags"`
+}
+
+type UserStoryAddSuccessModel struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+}
+
+// Story is a row of the usersStory table with its decoded JSON document.
+type Story struct {
+	ID      int
+	UserID  int
+	Status  string
+	Type    string
+	Content map[string]interface{}
+	// Tags are the names of the story's tags, in alphabetical order.
+	Tags []string
+	// Comments is how many comments and replies are shown on the story.
+	Comments  int
+	CreatedAt time.Time
+}
+
+// Review statuses of a story. Stories are created as drafts and are only
+// shown publicly once published.
+const (
+	StoryDraft         = "draft"
+	StoryPendingReview = "pending_review"
+	StoryPublished     = "published"
+	StoryRejected      = "rejected"
+)
+
+// StoryStatuses lists every review status.
+var StoryStatuses = []string{StoryDraft, StoryPendingReview, StoryPublished, StoryRejected}
+
+// storyTransitions lists the statuses a story can move to from each
+// status.
+var storyTransitions = map[string][]string{
+	// Authors submit drafts for review
+	StoryDraft: {StoryPendingReview},
+	// Moderators approve or reject; authors can withdraw
+	StoryPendingReview: {StoryPublished, StoryRejected, StoryDraft},
+	// Authors editing a published story send it back to review;
+	// moderators can take it down
+	StoryPublished: {StoryPendingReview, StoryRejected},
+	// Authors resubmit rejected stories once they have fixed them
+	StoryRejected: {StoryPendingReview},
 }
 
-type UserStoryAddSuccessModel struct{
-    Status  bool        `json:"status"`
-	Message string      `json:"message"`
-}
\ No newline at end of file
+// ValidStoryStatus reports whether status is one of StoryStatuses.
+func ValidStoryStatus(status string) bool {
+	_, ok := storyTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether a story may move from one status to
+// another.
+func CanTransition(from, to string) bool {
+	for _, allowed := range storyTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StoryTransition records one status change of a story.
+type StoryTransition struct {
+	ID      int    `json:"id"`
+	StoryID int    `json:"story_id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	// ActorID is the user who made the change; it is 0 once that user
+	// has been deleted.
+	ActorID   int       `json:"actor_id"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RejectStoryRequest is the body accepted by
+// POST /api/moderation/stories/{id}/reject.
+type RejectStoryRequest struct {
+	Reason string `json:"reason"`
+}
+
+// StoryListModel is a page of stories returned by
+// GET /api/moderation/stories.
+type StoryListModel struct {
+	Stories []map[string]interface{} `json:"stories"`
+	Page    int                      `json:"page"`
+	PerPage int                      `json:"per_page"`
+	Total   int                      `json:"total"`
+}
+
+// Orders of the public story feed.
+const (
+	StorySortNewest    = "newest"
+	StorySortMostLiked = "most_liked"
+)
+
+// StoryFeedQuery selects a page of the public story feed.
+type StoryFeedQuery struct {
+	// AuthorID and Tag, when set, only keep the stories of that author
+	// or carrying that tag, whatever the case of its name.
+	AuthorID int
+	Tag      string
+	// Sort is StorySortNewest or StorySortMostLiked.
+	Sort string
+	// After, when set, continues the feed after the given story.
+	After *StoryFeedCursor
+	Limit int
+}
+
+// StoryFeedCursor is the position of a story in the public feed.
+type StoryFeedCursor struct {
+	Likes     int
+	CreatedAt time.Time
+	ID        int
+}
+
+// PublicStory is a published story with what the public feed shows about
+// its author.
+type PublicStory struct {
+	Story
+	AuthorName       string
+	AuthorProfilePic string
+	Likes            int
+}
+
+// StoryAuthorModel is the public view of the author of a story.
+type StoryAuthorModel struct {
+	ID                 int               `json:"id"`
+	FullName           string            `json:"full_name"`
+	ProfilePic         string            `json:"profile_pic"`
+	ProfilePicVariants map[string]string `json:"profile_pic_variants,omitempty"`
+}
+
+// PublicStoryModel is a story as returned by GET /api/public/stories.
+type PublicStoryModel struct {
+	ID        int                    `json:"id"`
+	Type      string                 `json:"type"`
+	Story     map[string]interface{} `json:"story"`
+	Tags      []string               `json:"tags"`
+	Author    StoryAuthorModel       `json:"author"`
+	Likes     int                    `json:"likes"`
+	Comments  int                    `json:"comments"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// PublicStoryFeedModel is a page of the public story feed. NextCursor is
+// empty on the last page.
+type PublicStoryFeedModel struct {
+	Stories    []PublicStoryModel `json:"stories"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// StoryLikesModel is returned after liking or unliking a story.
+type StoryLikesModel struct {
+	Likes int `json:"likes"`
+}
+
+// StoryRevision is an immutable version of the type and document of a
+// story. Revisions are numbered from 1 for every story.
+type StoryRevision struct {
+	StoryID  int    `json:"story_id"`
+	Revision int    `json:"revision"`
+	Type     string `json:"type"`
+	// Content is left out when revisions are listed.
+	Content map[string]interface{} `json:"story,omitempty"`
+	// ContentHash is the hex SHA-256 of the canonical JSON encoding of
+	// Content, so identical versions are easy to spot.
+	ContentHash string `json:"content_hash"`
+	// AuthorID is the user who wrote the revision; it is 0 once that user
+	// has been deleted.
+	AuthorID int `json:"author_id"`
+	// RestoredFrom is the revision this one is a copy of, if it was made
+	// by restoring an older revision.
+	RestoredFrom int       `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// JSONChange is one difference between two JSON documents. Op is add,
+// remove or replace and Path is a JSON Pointer (RFC 6901) to the value
+// that changed. From is null for added values and To for removed ones;
+// both are always written, so a change from or to null keeps it.
+type JSONChange struct {
+	Op   string      `json:"op"`
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// StoryDiffModel lists what changed in a story between two revisions.
+type StoryDiffModel struct {
+	From     int          `json:"from"`
+	To       int          `json:"to"`
+	FromType string       `json:"from_type"`
+	ToType   string       `json:"to_type"`
+	Changes  []JSONChange `json:"changes"`
+}
+
+// StoryTypeModel describes a story type and the JSON Schema its stories
+// must match.
+type StoryTypeModel struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+}
+
+// FieldError is a problem with one field of a request. Field is the path
+// of the field, with dots between object members and brackets around
+// array indexes, such as "metrics[0].label".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// StoryValidationModel lists why a story does not match its type.
+type StoryValidationModel struct {
+	Type   string       `json:"type"`
+	Errors []FieldError `json:"errors"`
+}
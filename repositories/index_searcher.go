@@ -0,0 +1,240 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/search"
+)
+
+// indexSearcher implements Searcher with in-process inverted indexes of
+// the published stories and of the users, for SQLite and the in-memory
+// repositories.
+//
+// The indexes are built from the repositories on the first search. Every
+// write through the repositories returned by withSearchIndex marks them
+// stale, and the next search builds them again from scratch, which is
+// only reasonable for the small databases those setups hold.
+type indexSearcher struct {
+	stories StoryRepository
+	users   UserRepository
+
+	// version counts the writes; built is the version the indexes were
+	// built at.
+	version atomic.Int64
+
+	mu    sync.Mutex
+	built int64
+	index *searchIndex
+}
+
+// searchIndex is one build of the indexes with the rows they point to.
+type searchIndex struct {
+	stories      *search.Index
+	storiesByID  map[int]models.PublicStory
+	users        *search.Index
+	usersByID    map[int]models.RegisterUserModel
+	storiesCount int
+}
+
+// indexPageSize is how many rows are read at a time to build the indexes.
+const indexPageSize = 500
+
+// withSearchIndex sets repos.Search to an indexSearcher and wraps the
+// story and user repositories so their writes keep it up to date.
+func withSearchIndex(repos *Repositories) *Repositories {
+	searcher := &indexSearcher{stories: repos.Stories, users: repos.Users}
+	searcher.version.Store(1)
+	repos.Stories = indexedStories{StoryRepository: repos.Stories, searcher: searcher}
+	repos.Users = indexedUsers{UserRepository: repos.Users, searcher: searcher}
+	repos.Search = searcher
+	return repos
+}
+
+func (s *indexSearcher) SearchStories(ctx context.Context, query string, limit int) ([]models.StorySearchHit, error) {
+	index, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var hits []models.StorySearchHit
+	for _, match := range index.stories.Search(query, limit) {
+		hits = append(hits, models.StorySearchHit{Story: index.storiesByID[match.ID], Score: match.Score})
+	}
+	return hits, nil
+}
+
+func (s *indexSearcher) SearchUsers(ctx context.Context, query string, limit int) ([]models.UserSearchHit, error) {
+	index, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var hits []models.UserSearchHit
+	for _, match := range index.users.Search(query, limit) {
+		hits = append(hits, models.UserSearchHit{User: index.usersByID[match.ID], Score: match.Score})
+	}
+	return hits, nil
+}
+
+func (s *indexSearcher) Reindex(ctx context.Context) (int, error) {
+	s.invalidate()
+	index, err := s.current(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return index.storiesCount, nil
+}
+
+// invalidate marks the indexes stale after a write.
+func (s *indexSearcher) invalidate() {
+	s.version.Add(1)
+}
+
+// current returns indexes no older than the last write, building them
+// when needed. Searches wait for a build in progress rather than start
+// another one.
+func (s *indexSearcher) current(ctx context.Context) (*searchIndex, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version := s.version.Load()
+	if s.index != nil && s.built == version {
+		return s.index, nil
+	}
+	index, err := s.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Writes made during the build may be missing; keep it stale for them
+	s.index, s.built = index, version
+	return index, nil
+}
+
+// build reads every published story and every user into new indexes.
+func (s *indexSearcher) build(ctx context.Context) (*searchIndex, error) {
+	index := &searchIndex{
+		stories:     search.NewIndex(),
+		storiesByID: map[int]models.PublicStory{},
+		users:       search.NewIndex(),
+		usersByID:   map[int]models.RegisterUserModel{},
+	}
+
+	q := models.StoryFeedQuery{Sort: models.StorySortNewest, Limit: indexPageSize}
+	for {
+		stories, err := s.stories.ListPublished(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, story := range stories {
+			index.stories.Add(story.ID, search.DocumentText(story.Content))
+			index.storiesByID[story.ID] = story
+		}
+		if len(stories) < indexPageSize {
+			break
+		}
+		last := stories[len(stories)-1]
+		q.After = &models.StoryFeedCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	index.storiesCount = index.stories.Len()
+
+	for offset := 0; ; offset += indexPageSize {
+		users, _, err := s.users.Search(ctx, "", offset, indexPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			index.users.Add(user.ID, user.FullName+"\n"+user.Email)
+			index.usersByID[user.ID] = user
+		}
+		if len(users) < indexPageSize {
+			break
+		}
+	}
+	return index, nil
+}
+
+// indexedStories is a StoryRepository whose writes mark the search
+// indexes stale.
+type indexedStories struct {
+	StoryRepository
+	searcher *indexSearcher
+}
+
+func (r indexedStories) Create(ctx context.Context, userID int, storyType string, content map[string]interface{}, tags []string) (int, error) {
+	defer r.searcher.invalidate()
+	return r.StoryRepository.Create(ctx, userID, storyType, content, tags)
+}
+
+func (r indexedStories) Update(ctx context.Context, rev models.StoryRevision, tags []string, t *models.StoryTransition) (models.StoryRevision, error) {
+	defer r.searcher.invalidate()
+	return r.StoryRepository.Update(ctx, rev, tags, t)
+}
+
+func (r indexedStories) Delete(ctx context.Context, id int) error {
+	defer r.searcher.invalidate()
+	return r.StoryRepository.Delete(ctx, id)
+}
+
+func (r indexedStories) DeleteByUser(ctx context.Context, userID int) error {
+	defer r.searcher.invalidate()
+	return r.StoryRepository.DeleteByUser(ctx, userID)
+}
+
+func (r indexedStories) Transition(ctx context.Context, t models.StoryTransition) (bool, error) {
+	defer r.searcher.invalidate()
+	return r.StoryRepository.Transition(ctx, t)
+}
+
+func (r indexedStories) Like(ctx context.Context, storyID, userID int, at time.Time) error {
+	defer r.searcher.invalidate()
+	return r.StoryRepository.Like(ctx, storyID, userID, at)
+}
+
+func (r indexedStories) Unlike(ctx context.Context, storyID, userID int) error {
+	defer r.searcher.invalidate()
+	return r.StoryRepository.Unlike(ctx, storyID, userID)
+}
+
+// indexedUsers is a UserRepository whose writes to what searches return
+// mark the search indexes stale.
+type indexedUsers struct {
+	UserRepository
+	searcher *indexSearcher
+}
+
+func (r indexedUsers) Create(ctx context.Context, user models.RegisterUserModel) (int, error) {
+	defer r.searcher.invalidate()
+	return r.UserRepository.Create(ctx, user)
+}
+
+func (r indexedUsers) UpdateProfile(ctx context.Context, id int, fullName, email string) error {
+	defer r.searcher.invalidate()
+	return r.UserRepository.UpdateProfile(ctx, id, fullName, email)
+}
+
+func (r indexedUsers) UpdateProfilePic(ctx context.Context, id int, profilePic string) error {
+	defer r.searcher.invalidate()
+	return r.UserRepository.UpdateProfilePic(ctx, id, profilePic)
+}
+
+func (r indexedUsers) UpdateRole(ctx context.Context, id int, role string) error {
+	defer r.searcher.invalidate()
+	return r.UserRepository.UpdateRole(ctx, id, role)
+}
+
+func (r indexedUsers) SetDisabledAt(ctx context.Context, id int, at *time.Time) error {
+	defer r.searcher.invalidate()
+	return r.UserRepository.SetDisabledAt(ctx, id, at)
+}
+
+func (r indexedUsers) SetDeletedAt(ctx context.Context, id int, at *time.Time) error {
+	defer r.searcher.invalidate()
+	return r.UserRepository.SetDeletedAt(ctx, id, at)
+}
+
+func (r indexedUsers) Delete(ctx context.Context, id int) error {
+	defer r.searcher.invalidate()
+	return r.UserRepository.Delete(ctx, id)
+}
@@ -0,0 +1,1062 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blog_project.com/models"
+)
+
+// NewMemory returns repositories that keep everything in process memory.
+//
+// They are meant for tests and throwaway local runs; nothing survives a
+// restart. They are searched through an in-process index.
+func NewMemory() *Repositories {
+	users := &memoryUserRepository{users: map[int]models.RegisterUserModel{}, sentAt: map[int]time.Time{}}
+	stories := &memoryStoryRepository{
+		users:       users,
+		stories:     map[int]models.Story{},
+		transitions: map[int][]models.StoryTransition{},
+		revisions:   map[int][]models.StoryRevision{},
+		likes:       map[int]map[int]bool{},
+		tags:        map[int]models.Tag{},
+		storyTags:   map[int]map[int]bool{},
+		comments:    map[int]models.Comment{},
+	}
+	return withSearchIndex(&Repositories{
+		Users:          users,
+		Stories:        stories,
+		Tags:           &memoryTagRepository{stories: stories},
+		Comments:       &memoryCommentRepository{stories: stories},
+		PasswordResets: &memoryPasswordResetRepository{tokens: map[int]models.PasswordResetToken{}},
+		Tokens: &memoryTokenRepository{
+			refresh:      map[int]models.RefreshToken{},
+			revoked:      map[string]time.Time{},
+			userCutoffs:  map[int]time.Time{},
+			userExpiries: map[int]time.Time{},
+		},
+	})
+}
+
+type memoryUserRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	users  map[int]models.RegisterUserModel
+	sentAt map[int]time.Time // user ID -> last verification mail
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user models.RegisterUserModel) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return 0, ErrDuplicateEmail
+		}
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	r.nextID++
+	user.ID = r.nextID
+	r.users[user.ID] = user
+	return user.ID, nil
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id int) (models.RegisterUserModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.RegisterUserModel{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (models.RegisterUserModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return models.RegisterUserModel{}, ErrNotFound
+}
+
+func (r *memoryUserRepository) UpdateProfile(ctx context.Context, id int, fullName, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, existing := range r.users {
+		if existing.ID != id && strings.EqualFold(existing.Email, email) {
+			return ErrDuplicateEmail
+		}
+	}
+	if !strings.EqualFold(user.Email, email) {
+		user.EmailVerifiedAt = nil
+	}
+	user.FullName = fullName
+	user.Email = email
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) UpdateProfilePic(ctx context.Context, id int, profilePic string) error {
+	return r.update(id, func(user *models.RegisterUserModel) { user.ProfilePic = profilePic })
+}
+
+func (r *memoryUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	return r.update(id, func(user *models.RegisterUserModel) { user.Password = passwordHash })
+}
+
+func (r *memoryUserRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	return r.update(id, func(user *models.RegisterUserModel) { user.Role = role })
+}
+
+func (r *memoryUserRepository) MarkEmailVerified(ctx context.Context, id int, email string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !strings.EqualFold(user.Email, email) {
+		return false, nil
+	}
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &at
+		r.users[id] = user
+	}
+	return true, nil
+}
+
+func (r *memoryUserRepository) ClaimVerificationSend(ctx context.Context, id int, at, notBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return false, nil
+	}
+	if last, ok := r.sentAt[id]; ok && last.After(notBefore) {
+		return false, nil
+	}
+	r.sentAt[id] = at
+	return true, nil
+}
+
+func (r *memoryUserRepository) SetDeletedAt(ctx context.Context, id int, at *time.Time) error {
+	return r.update(id, func(user *models.RegisterUserModel) { user.DeletedAt = at })
+}
+
+func (r *memoryUserRepository) ListDeletedBefore(ctx context.Context, before time.Time) ([]models.RegisterUserModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []models.RegisterUserModel
+	for _, user := range r.users {
+		if user.DeletedAt != nil && user.DeletedAt.Before(before) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r *memoryUserRepository) Search(ctx context.Context, query string, offset, limit int) ([]models.RegisterUserModel, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query = strings.ToLower(query)
+	var matches []models.RegisterUserModel
+	for _, user := range r.users {
+		if strings.Contains(strings.ToLower(user.FullName), query) || strings.Contains(strings.ToLower(user.Email), query) {
+			matches = append(matches, user)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+
+	total := len(matches)
+	if offset >= total {
+		return nil, total, nil
+	}
+	matches = matches[offset:]
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, total, nil
+}
+
+func (r *memoryUserRepository) SetDisabledAt(ctx context.Context, id int, at *time.Time) error {
+	return r.update(id, func(user *models.RegisterUserModel) { user.DisabledAt = at })
+}
+
+func (r *memoryUserRepository) DisabledIDs(ctx context.Context) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []int
+	for id, user := range r.users {
+		if user.DisabledAt != nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Delete only removes the user; unlike the SQL repositories there are no
+// foreign keys, so callers delete the user's stories themselves.
+func (r *memoryUserRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	delete(r.sentAt, id)
+	return nil
+}
+
+func (r *memoryUserRepository) update(id int, change func(*models.RegisterUserModel)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	change(&user)
+	r.users[id] = user
+	return nil
+}
+
+type memoryStoryRepository struct {
+	users *memoryUserRepository // read for the authors of the public feed
+
+	mu               sync.RWMutex
+	nextID           int
+	stories          map[int]models.Story
+	nextTransitionID int
+	transitions      map[int][]models.StoryTransition // story ID -> status changes
+	revisions        map[int][]models.StoryRevision   // story ID -> revisions, with their documents
+	likes            map[int]map[int]bool             // story ID -> IDs of the users who like it
+	nextTagID        int
+	tags             map[int]models.Tag   // tag ID -> tag, without its story count
+	storyTags        map[int]map[int]bool // story ID -> IDs of its tags
+	nextCommentID    int
+	comments         map[int]models.Comment // comment ID -> comment, without its replies
+}
+
+func (r *memoryStoryRepository) Create(ctx context.Context, userID int, storyType string, content map[string]interface{}, tags []string) (int, error) {
+	content, err := copyDocument(content)
+	if err != nil {
+		return 0, err
+	}
+	_, hash, err := encodeRevision(content)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	now := time.Now().UTC().Truncate(time.Second)
+	r.stories[r.nextID] = models.Story{
+		ID:        r.nextID,
+		UserID:    userID,
+		Status:    models.StoryDraft,
+		Type:      storyType,
+		Content:   content,
+		CreatedAt: now,
+	}
+	r.revisions[r.nextID] = []models.StoryRevision{{
+		StoryID:     r.nextID,
+		Revision:    1,
+		Type:        storyType,
+		Content:     content,
+		ContentHash: hash,
+		AuthorID:    userID,
+		CreatedAt:   now,
+	}}
+	r.setTags(r.nextID, tags, now)
+	return r.nextID, nil
+}
+
+func (r *memoryStoryRepository) FindByID(ctx context.Context, id int) (models.Story, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	story, ok := r.stories[id]
+	if !ok {
+		return models.Story{}, ErrNotFound
+	}
+	return r.copyStory(story)
+}
+
+func (r *memoryStoryRepository) ListByUser(ctx context.Context, userID int) ([]models.Story, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stories []models.Story
+	for _, story := range r.stories {
+		if story.UserID != userID {
+			continue
+		}
+		story, err := r.copyStory(story)
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+	}
+	sort.Slice(stories, func(i, j int) bool { return stories[i].ID < stories[j].ID })
+	return stories, nil
+}
+
+func (r *memoryStoryRepository) CountByUser(ctx context.Context, userID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, story := range r.stories {
+		if story.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Update needs no baseline revision for older stories, unlike the SQL
+// repository: every story created here got its first revision.
+func (r *memoryStoryRepository) Update(ctx context.Context, rev models.StoryRevision, tags []string, t *models.StoryTransition) (models.StoryRevision, error) {
+	content, err := copyDocument(rev.Content)
+	if err != nil {
+		return rev, err
+	}
+	_, hash, err := encodeRevision(content)
+	if err != nil {
+		return rev, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	story, ok := r.stories[rev.StoryID]
+	if !ok {
+		return rev, ErrNotFound
+	}
+	if t != nil && story.Status != t.From {
+		return rev, ErrStatusChanged
+	}
+	story.Type, story.Content = rev.Type, content
+	r.stories[rev.StoryID] = story
+	if t != nil {
+		r.transition(*t)
+	}
+
+	stored := rev
+	stored.Revision = len(r.revisions[rev.StoryID]) + 1
+	stored.Content, stored.ContentHash = content, hash
+	stored.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	r.revisions[rev.StoryID] = append(r.revisions[rev.StoryID], stored)
+	if tags != nil {
+		r.setTags(rev.StoryID, tags, stored.CreatedAt)
+	}
+
+	rev.Revision, rev.ContentHash, rev.CreatedAt = stored.Revision, stored.ContentHash, stored.CreatedAt
+	return rev, nil
+}
+
+func (r *memoryStoryRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.stories[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.stories, id)
+	delete(r.transitions, id)
+	delete(r.revisions, id)
+	delete(r.likes, id)
+	delete(r.storyTags, id)
+	r.deleteComments(id)
+	return nil
+}
+
+func (r *memoryStoryRepository) DeleteByUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, story := range r.stories {
+		if story.UserID == userID {
+			delete(r.stories, id)
+			delete(r.transitions, id)
+			delete(r.revisions, id)
+			delete(r.likes, id)
+			delete(r.storyTags, id)
+			r.deleteComments(id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryStoryRepository) ListByStatus(ctx context.Context, status string, offset, limit int) ([]models.Story, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stories []models.Story
+	for _, story := range r.stories {
+		if story.Status == status {
+			stories = append(stories, story)
+		}
+	}
+	sort.Slice(stories, func(i, j int) bool { return stories[i].ID < stories[j].ID })
+
+	total := len(stories)
+	if offset >= total {
+		return nil, total, nil
+	}
+	stories = stories[offset:]
+	if len(stories) > limit {
+		stories = stories[:limit]
+	}
+	for i := range stories {
+		story, err := r.copyStory(stories[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		stories[i] = story
+	}
+	return stories, total, nil
+}
+
+func (r *memoryStoryRepository) Transition(ctx context.Context, t models.StoryTransition) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	story, ok := r.stories[t.StoryID]
+	if !ok || story.Status != t.From {
+		return false, nil
+	}
+	r.transition(t)
+	return true, nil
+}
+
+// transition moves a story that exists to t.To and records t. The caller
+// must hold r.mu.
+func (r *memoryStoryRepository) transition(t models.StoryTransition) {
+	story := r.stories[t.StoryID]
+	story.Status = t.To
+	r.stories[t.StoryID] = story
+
+	r.nextTransitionID++
+	t.ID = r.nextTransitionID
+	r.transitions[t.StoryID] = append(r.transitions[t.StoryID], t)
+}
+
+func (r *memoryStoryRepository) ListTransitions(ctx context.Context, storyID int) ([]models.StoryTransition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.StoryTransition(nil), r.transitions[storyID]...), nil
+}
+
+func (r *memoryStoryRepository) ListRevisions(ctx context.Context, storyID int) ([]models.StoryRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var revisions []models.StoryRevision
+	for _, rev := range r.revisions[storyID] {
+		rev.Content = nil
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+func (r *memoryStoryRepository) FindRevision(ctx context.Context, storyID, revision int) (models.StoryRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := r.revisions[storyID]
+	if revision < 1 || revision > len(revisions) {
+		return models.StoryRevision{}, ErrNotFound
+	}
+	rev := revisions[revision-1]
+	content, err := copyDocument(rev.Content)
+	rev.Content = content
+	return rev, err
+}
+
+func (r *memoryStoryRepository) ListPublished(ctx context.Context, q models.StoryFeedQuery) ([]models.PublicStory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.users.mu.RLock()
+	defer r.users.mu.RUnlock()
+
+	var stories []models.PublicStory
+	for _, story := range r.stories {
+		author, ok := r.users.users[story.UserID]
+		if story.Status != models.StoryPublished || !ok || author.DisabledAt != nil || author.DeletedAt != nil {
+			continue
+		}
+		if q.AuthorID != 0 && story.UserID != q.AuthorID {
+			continue
+		}
+		if q.Tag != "" && !r.hasTag(story.ID, q.Tag) {
+			continue
+		}
+		stories = append(stories, models.PublicStory{
+			Story:            story,
+			AuthorName:       author.FullName,
+			AuthorProfilePic: author.ProfilePic,
+			Likes:            len(r.likes[story.ID]),
+		})
+	}
+
+	// before reports whether a comes first in the requested order
+	before := func(a, b models.StoryFeedCursor) bool {
+		if q.Sort == models.StorySortMostLiked && a.Likes != b.Likes {
+			return a.Likes > b.Likes
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	}
+	cursor := func(story models.PublicStory) models.StoryFeedCursor {
+		return models.StoryFeedCursor{Likes: story.Likes, CreatedAt: story.CreatedAt, ID: story.ID}
+	}
+	sort.Slice(stories, func(i, j int) bool { return before(cursor(stories[i]), cursor(stories[j])) })
+
+	page := []models.PublicStory{}
+	for _, story := range stories {
+		if q.After != nil && !before(*q.After, cursor(story)) {
+			continue
+		}
+		if len(page) == q.Limit {
+			break
+		}
+		copied, err := r.copyStory(story.Story)
+		if err != nil {
+			return nil, err
+		}
+		story.Story = copied
+		page = append(page, story)
+	}
+	return page, nil
+}
+
+func (r *memoryStoryRepository) Like(ctx context.Context, storyID, userID int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.likes[storyID] == nil {
+		r.likes[storyID] = map[int]bool{}
+	}
+	r.likes[storyID][userID] = true
+	return nil
+}
+
+func (r *memoryStoryRepository) Unlike(ctx context.Context, storyID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.likes[storyID], userID)
+	return nil
+}
+
+func (r *memoryStoryRepository) CountLikes(ctx context.Context, storyID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.likes[storyID]), nil
+}
+
+// setTags replaces the tags of a story with the named ones, creating
+// the missing tags at the given time. The caller holds r.mu.
+func (r *memoryStoryRepository) setTags(storyID int, names []string, at time.Time) {
+	tagIDs := map[int]bool{}
+	for _, name := range names {
+		tag, ok := r.findTag(name)
+		if !ok {
+			r.nextTagID++
+			tag = models.Tag{ID: r.nextTagID, Name: name, CreatedAt: at}
+			r.tags[tag.ID] = tag
+		}
+		tagIDs[tag.ID] = true
+	}
+	r.storyTags[storyID] = tagIDs
+}
+
+// findTag returns the tag called name regardless of case. The caller
+// holds r.mu.
+func (r *memoryStoryRepository) findTag(name string) (models.Tag, bool) {
+	for _, tag := range r.tags {
+		if strings.EqualFold(tag.Name, name) {
+			return tag, true
+		}
+	}
+	return models.Tag{}, false
+}
+
+// hasTag reports whether a story carries the tag called name regardless
+// of case. The caller holds r.mu.
+func (r *memoryStoryRepository) hasTag(storyID int, name string) bool {
+	tag, ok := r.findTag(name)
+	return ok && r.storyTags[storyID][tag.ID]
+}
+
+// copyStory deep-copies a stored story and sets its tags and comment
+// count. The caller holds r.mu.
+func (r *memoryStoryRepository) copyStory(story models.Story) (models.Story, error) {
+	story.Tags = nil
+	for tagID := range r.storyTags[story.ID] {
+		story.Tags = append(story.Tags, r.tags[tagID].Name)
+	}
+	sortTagNames(story.Tags)
+	story.Comments = 0
+	for _, comment := range r.comments {
+		if comment.StoryID == story.ID && !comment.Removed() {
+			story.Comments++
+		}
+	}
+	return copyStory(story)
+}
+
+// deleteComments removes the comments on a story. The caller holds r.mu.
+func (r *memoryStoryRepository) deleteComments(storyID int) {
+	for id, comment := range r.comments {
+		if comment.StoryID == storyID {
+			delete(r.comments, id)
+		}
+	}
+}
+
+// sortTagNames sorts tag names alphabetically regardless of case, like
+// the collation of the SQL tables.
+func sortTagNames(names []string) {
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+}
+
+// memoryTagRepository keeps its tags in the story repository, so tagging
+// a story and deleting it stay consistent under one lock.
+type memoryTagRepository struct {
+	stories *memoryStoryRepository
+}
+
+func (r *memoryTagRepository) List(ctx context.Context, published bool) ([]models.Tag, error) {
+	s := r.stories
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.users.mu.RLock()
+	defer s.users.mu.RUnlock()
+
+	counts := map[int]int{}
+	for storyID, tagIDs := range s.storyTags {
+		story := s.stories[storyID]
+		author, ok := s.users.users[story.UserID]
+		if published && (story.Status != models.StoryPublished || !ok || author.DisabledAt != nil || author.DeletedAt != nil) {
+			continue
+		}
+		for tagID := range tagIDs {
+			counts[tagID]++
+		}
+	}
+
+	var tags []models.Tag
+	for _, tag := range s.tags {
+		if published && counts[tag.ID] == 0 {
+			continue
+		}
+		tag.Stories = counts[tag.ID]
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name) })
+	return tags, nil
+}
+
+func (r *memoryTagRepository) FindByID(ctx context.Context, id int) (models.Tag, error) {
+	s := r.stories
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tag, ok := s.tags[id]
+	if !ok {
+		return models.Tag{}, ErrNotFound
+	}
+	for _, tagIDs := range s.storyTags {
+		if tagIDs[id] {
+			tag.Stories++
+		}
+	}
+	return tag, nil
+}
+
+func (r *memoryTagRepository) Rename(ctx context.Context, id int, name string) error {
+	s := r.stories
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, ok := s.tags[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if other, ok := s.findTag(name); ok && other.ID != id {
+		return ErrDuplicateTag
+	}
+	tag.Name = name
+	s.tags[id] = tag
+	return nil
+}
+
+func (r *memoryTagRepository) Merge(ctx context.Context, from, into int) error {
+	s := r.stories
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, fromOK := s.tags[from]
+	_, intoOK := s.tags[into]
+	if !fromOK || !intoOK {
+		return ErrNotFound
+	}
+	for _, tagIDs := range s.storyTags {
+		if tagIDs[from] {
+			delete(tagIDs, from)
+			tagIDs[into] = true
+		}
+	}
+	delete(s.tags, from)
+	return nil
+}
+
+// memoryCommentRepository keeps its comments in the story repository, so
+// deleting a story deletes its comments under one lock.
+type memoryCommentRepository struct {
+	stories *memoryStoryRepository
+}
+
+func (r *memoryCommentRepository) Create(ctx context.Context, comment models.Comment) (int, error) {
+	s := r.stories
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextCommentID++
+	comment.ID = s.nextCommentID
+	comment.Replies = nil
+	s.comments[comment.ID] = comment
+	return comment.ID, nil
+}
+
+func (r *memoryCommentRepository) FindByID(ctx context.Context, id int) (models.Comment, error) {
+	s := r.stories
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.users.mu.RLock()
+	defer s.users.mu.RUnlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return models.Comment{}, ErrNotFound
+	}
+	return r.withAuthor(comment), nil
+}
+
+func (r *memoryCommentRepository) ListByStory(ctx context.Context, storyID, offset, limit int) ([]models.Comment, int, error) {
+	s := r.stories
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.users.mu.RLock()
+	defer s.users.mu.RUnlock()
+
+	var comments []models.Comment
+	replies := map[int][]models.Comment{}
+	for _, comment := range s.comments {
+		if comment.StoryID != storyID {
+			continue
+		}
+		if comment.ParentID == 0 {
+			comments = append(comments, r.withAuthor(comment))
+		} else if !comment.Removed() {
+			replies[comment.ParentID] = append(replies[comment.ParentID], r.withAuthor(comment))
+		}
+	}
+
+	shown := comments[:0]
+	for _, comment := range comments {
+		if comment.Removed() && len(replies[comment.ID]) == 0 {
+			continue
+		}
+		comment.Replies = replies[comment.ID]
+		sort.Slice(comment.Replies, func(i, j int) bool { return comment.Replies[i].ID < comment.Replies[j].ID })
+		shown = append(shown, comment)
+	}
+	sort.Slice(shown, func(i, j int) bool { return shown[i].ID < shown[j].ID })
+
+	total := len(shown)
+	if offset >= total {
+		return nil, total, nil
+	}
+	shown = shown[offset:]
+	if len(shown) > limit {
+		shown = shown[:limit]
+	}
+	return shown, total, nil
+}
+
+func (r *memoryCommentRepository) UpdateBody(ctx context.Context, id int, body string, at time.Time) error {
+	return r.update(id, func(comment *models.Comment) {
+		comment.Body, comment.EditedAt = body, &at
+	})
+}
+
+func (r *memoryCommentRepository) Delete(ctx context.Context, id int, at time.Time) error {
+	return r.update(id, func(comment *models.Comment) {
+		comment.Body, comment.DeletedAt = "", &at
+	})
+}
+
+func (r *memoryCommentRepository) DeleteByUser(ctx context.Context, userID int, at time.Time) error {
+	s := r.stories
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, comment := range s.comments {
+		if comment.UserID == userID && comment.DeletedAt == nil {
+			comment.Body, comment.DeletedAt = "", &at
+			s.comments[id] = comment
+		}
+	}
+	return nil
+}
+
+func (r *memoryCommentRepository) SetHiddenAt(ctx context.Context, id int, at *time.Time) error {
+	return r.update(id, func(comment *models.Comment) {
+		comment.HiddenAt = at
+	})
+}
+
+// withAuthor returns comment with the name and avatar of its author. The
+// caller holds the story and user locks.
+func (r *memoryCommentRepository) withAuthor(comment models.Comment) models.Comment {
+	author := r.stories.users.users[comment.UserID]
+	comment.AuthorName, comment.AuthorProfilePic = author.FullName, author.ProfilePic
+	return comment
+}
+
+func (r *memoryCommentRepository) update(id int, change func(*models.Comment)) error {
+	s := r.stories
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return ErrNotFound
+	}
+	change(&comment)
+	s.comments[id] = comment
+	return nil
+}
+
+type memoryTokenRepository struct {
+	mu           sync.Mutex
+	nextID       int
+	refresh      map[int]models.RefreshToken
+	revoked      map[string]time.Time
+	userCutoffs  map[int]time.Time
+	userExpiries map[int]time.Time
+}
+
+func (r *memoryTokenRepository) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	token.ID = r.nextID
+	r.refresh[token.ID] = token
+	return nil
+}
+
+func (r *memoryTokenRepository) FindRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.refresh {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return models.RefreshToken{}, ErrNotFound
+}
+
+func (r *memoryTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id int, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refresh[id]
+	if !ok || token.Spent() {
+		return false, nil
+	}
+	token.UsedAt = &at
+	r.refresh[id] = token
+	return true, nil
+}
+
+func (r *memoryTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	r.revokeRefreshTokens(func(token models.RefreshToken) bool { return token.FamilyID == familyID }, at)
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID int, at time.Time) error {
+	r.revokeRefreshTokens(func(token models.RefreshToken) bool { return token.UserID == userID }, at)
+	return nil
+}
+
+func (r *memoryTokenRepository) revokeRefreshTokens(match func(models.RefreshToken) bool, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.refresh {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &at
+			r.refresh[id] = token
+		}
+	}
+}
+
+func (r *memoryTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revoked[tokenID] = expiresAt
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeUserAccessTokens(ctx context.Context, userID int, before, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.userCutoffs[userID] = before
+	r.userExpiries[userID] = expiresAt
+	return nil
+}
+
+func (r *memoryTokenRepository) PurgeExpiredRevocations(ctx context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tokenID, expiresAt := range r.revoked {
+		if !expiresAt.After(now) {
+			delete(r.revoked, tokenID)
+		}
+	}
+	for userID, expiresAt := range r.userExpiries {
+		if !expiresAt.After(now) {
+			delete(r.userExpiries, userID)
+			delete(r.userCutoffs, userID)
+		}
+	}
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokedAccessTokens(ctx context.Context) (models.RevokedAccessTokens, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revoked := models.RevokedAccessTokens{
+		Tokens: map[string]time.Time{},
+		Users:  map[int]time.Time{},
+	}
+	for tokenID, expiresAt := range r.revoked {
+		revoked.Tokens[tokenID] = expiresAt
+	}
+	for userID, cutoff := range r.userCutoffs {
+		revoked.Users[userID] = cutoff
+	}
+	return revoked, nil
+}
+
+type memoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	nextID int
+	tokens map[int]models.PasswordResetToken
+}
+
+func (r *memoryPasswordResetRepository) Create(ctx context.Context, token models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	token.ID = r.nextID
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *memoryPasswordResetRepository) FindByHash(ctx context.Context, tokenHash string) (models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return models.PasswordResetToken{}, ErrNotFound
+}
+
+func (r *memoryPasswordResetRepository) MarkUsed(ctx context.Context, id int, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &at
+	r.tokens[id] = token
+	return true, nil
+}
+
+func (r *memoryPasswordResetRepository) InvalidateUser(ctx context.Context, userID int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &at
+			r.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+// copyStory returns a story whose document shares no memory with the
+// stored one, so callers cannot mutate repository state by accident.
+func copyStory(story models.Story) (models.Story, error) {
+	content, err := copyDocument(story.Content)
+	story.Content = content
+	return story, err
+}
+
+// copyDocument deep-copies a JSON document the same way a round trip
+// through the database would.
+func copyDocument(content map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	copied := map[string]interface{}{}
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	if copied == nil {
+		copied = map[string]interface{}{}
+	}
+	return copied, nil
+}
@@ -0,0 +1,229 @@
+// Package repositories hides the storage behind the API.
+//
+// Handlers only talk to the interfaces declared here. The SQL
+// implementation runs on MySQL in production and on SQLite for local
+// development and CI; the in-memory one lets handlers run under httptest
+// without any database at all.
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"blog_project.com/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist.
+	ErrNotFound = errors.New("repositories: not found")
+
+	// ErrDuplicateEmail is returned when a user is created with an email
+	// address that is already registered.
+	ErrDuplicateEmail = errors.New("repositories: email already exists")
+
+	// ErrDuplicateTag is returned when a tag is renamed to the name of
+	// another tag.
+	ErrDuplicateTag = errors.New("repositories: tag already exists")
+
+	// ErrStatusChanged is returned when a story is updated along with a
+	// status change and its status is no longer the one the change moves
+	// it from.
+	ErrStatusChanged = errors.New("repositories: story status changed")
+)
+
+// UserRepository stores registered users.
+type UserRepository interface {
+	// Create inserts a user and returns its new ID. Users without a role
+	// are created as models.RoleUser.
+	Create(ctx context.Context, user models.RegisterUserModel) (int, error)
+	FindByID(ctx context.Context, id int) (models.RegisterUserModel, error)
+	FindByEmail(ctx context.Context, email string) (models.RegisterUserModel, error)
+	// UpdateProfile changes the name and email of a user, marking the
+	// email unverified when it changes. It returns ErrDuplicateEmail when
+	// the email belongs to another user.
+	UpdateProfile(ctx context.Context, id int, fullName, email string) error
+	UpdateProfilePic(ctx context.Context, id int, profilePic string) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	// UpdateRole sets the role of a user to one of models.Roles.
+	UpdateRole(ctx context.Context, id int, role string) error
+	// Search returns the users, ordered by ID, whose name or email
+	// contains query, skipping offset and returning at most limit of them,
+	// along with the total number of matches. An empty query matches
+	// everyone.
+	Search(ctx context.Context, query string, offset, limit int) ([]models.RegisterUserModel, int, error)
+	// SetDisabledAt disables the user, or enables them again when at is
+	// nil.
+	SetDisabledAt(ctx context.Context, id int, at *time.Time) error
+	// DisabledIDs returns the IDs of every disabled user.
+	DisabledIDs(ctx context.Context) ([]int, error)
+	// MarkEmailVerified records that the user verified email. It returns
+	// false when the user's email is no longer email.
+	MarkEmailVerified(ctx context.Context, id int, email string, at time.Time) (bool, error)
+	// ClaimVerificationSend atomically records that a verification mail is
+	// sent at the given time, unless one was already sent after notBefore.
+	// It returns false when the caller must not send one.
+	ClaimVerificationSend(ctx context.Context, id int, at, notBefore time.Time) (bool, error)
+	// SetDeletedAt schedules the user for deletion, or cancels a scheduled
+	// deletion when at is nil.
+	SetDeletedAt(ctx context.Context, id int, at *time.Time) error
+	// ListDeletedBefore returns the users whose deletion was requested
+	// before the given time.
+	ListDeletedBefore(ctx context.Context, before time.Time) ([]models.RegisterUserModel, error)
+	// Delete removes a user for good together with the rows that
+	// reference it.
+	Delete(ctx context.Context, id int) error
+}
+
+// StoryRepository stores the JSON story documents of users.
+//
+// The stories it returns carry the names of their tags and how many
+// comments they show.
+type StoryRepository interface {
+	// Create inserts a draft story of the given type with the named tags
+	// for userID, records it as the story's first revision and returns
+	// its new ID.
+	//
+	// Tags that do not exist yet are created. Names match existing tags
+	// regardless of case and must not repeat.
+	Create(ctx context.Context, userID int, storyType string, content map[string]interface{}, tags []string) (int, error)
+	FindByID(ctx context.Context, id int) (models.Story, error)
+	ListByUser(ctx context.Context, userID int) ([]models.Story, error)
+	// CountByUser returns how many stories userID has.
+	CountByUser(ctx context.Context, userID int) (int, error)
+	// Update replaces the type and document of story rev.StoryID with
+	// those of rev and records them as the story's next revision, written
+	// by rev.AuthorID. It returns the revision as stored.
+	//
+	// When tags is not nil, they replace the tags of the story in the
+	// same write, as with Create. When t is not nil, the story moves from
+	// t.From to t.To in the same write, as with Transition. If its status
+	// is no longer t.From, nothing is written and ErrStatusChanged is
+	// returned.
+	Update(ctx context.Context, rev models.StoryRevision, tags []string, t *models.StoryTransition) (models.StoryRevision, error)
+	Delete(ctx context.Context, id int) error
+	// DeleteByUser removes every story of userID.
+	DeleteByUser(ctx context.Context, userID int) error
+	// ListByStatus returns the stories with the given status, oldest
+	// first, skipping offset and returning at most limit of them, along
+	// with the total number of such stories.
+	ListByStatus(ctx context.Context, status string, offset, limit int) ([]models.Story, int, error)
+	// Transition atomically moves a story from t.From to t.To and records
+	// t. It returns false, changing nothing, when the story's status is
+	// no longer t.From.
+	Transition(ctx context.Context, t models.StoryTransition) (bool, error)
+	// ListTransitions returns the status changes of a story, oldest first.
+	ListTransitions(ctx context.Context, storyID int) ([]models.StoryTransition, error)
+	// ListRevisions returns the revisions of a story, oldest first,
+	// without their documents.
+	ListRevisions(ctx context.Context, storyID int) ([]models.StoryRevision, error)
+	// FindRevision returns a revision of a story with its document.
+	FindRevision(ctx context.Context, storyID, revision int) (models.StoryRevision, error)
+	// ListPublished returns a page of the published stories of enabled
+	// accounts, in the order and from the position q asks for.
+	ListPublished(ctx context.Context, q models.StoryFeedQuery) ([]models.PublicStory, error)
+	// Like records that userID likes a story; liking it twice is not an
+	// error.
+	Like(ctx context.Context, storyID, userID int, at time.Time) error
+	// Unlike removes the like of userID from a story, if any.
+	Unlike(ctx context.Context, storyID, userID int) error
+	// CountLikes returns how many users like a story.
+	CountLikes(ctx context.Context, storyID int) (int, error)
+}
+
+// TokenRepository stores refresh tokens and revoked access tokens.
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token models.RefreshToken) error
+	FindRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	// MarkRefreshTokenUsed atomically claims an unused, unrevoked token.
+	// It returns false if the token had already been used or revoked.
+	MarkRefreshTokenUsed(ctx context.Context, id int, at time.Time) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string, at time.Time) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int, at time.Time) error
+
+	RevokeAccessToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error
+	// RevokeUserAccessTokens revokes every access token of userID issued
+	// before the given time; the entry can be dropped after expiresAt.
+	RevokeUserAccessTokens(ctx context.Context, userID int, before, expiresAt time.Time) error
+	// PurgeExpiredRevocations deletes the revocations that expired before
+	// now.
+	PurgeExpiredRevocations(ctx context.Context, now time.Time) error
+	// RevokedAccessTokens returns every stored revocation.
+	RevokedAccessTokens(ctx context.Context) (models.RevokedAccessTokens, error)
+}
+
+// PasswordResetRepository stores password reset tokens.
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token models.PasswordResetToken) error
+	FindByHash(ctx context.Context, tokenHash string) (models.PasswordResetToken, error)
+	// MarkUsed atomically claims an unused token. It returns false if the
+	// token had already been used.
+	MarkUsed(ctx context.Context, id int, at time.Time) (bool, error)
+	// InvalidateUser marks every unused token of userID as used.
+	InvalidateUser(ctx context.Context, userID int, at time.Time) error
+}
+
+// TagRepository stores the tags stories are grouped by; stories are
+// tagged through StoryRepository.Create and Update.
+type TagRepository interface {
+	// List returns the tags, sorted by name, with how many stories carry
+	// each. When published is set, only the published stories of enabled
+	// accounts are counted and the tags none of them carry are left out.
+	List(ctx context.Context, published bool) ([]models.Tag, error)
+	// FindByID returns a tag with how many stories carry it.
+	FindByID(ctx context.Context, id int) (models.Tag, error)
+	// Rename changes the name of a tag. It returns ErrDuplicateTag when
+	// another tag has that name regardless of case.
+	Rename(ctx context.Context, id int, name string) error
+	// Merge moves the stories of tag from to tag into and deletes from.
+	Merge(ctx context.Context, from, into int) error
+}
+
+// CommentRepository stores the comments on stories.
+type CommentRepository interface {
+	// Create adds a comment, or a reply when comment.ParentID is set, and
+	// returns its new ID.
+	Create(ctx context.Context, comment models.Comment) (int, error)
+	// FindByID returns a comment without its replies.
+	FindByID(ctx context.Context, id int) (models.Comment, error)
+	// ListByStory returns the top-level comments of a story, oldest first,
+	// each with its replies, skipping offset and returning at most limit
+	// of them, along with the total number of such comments. Deleted and
+	// hidden comments are left out, unless they are top-level comments
+	// with replies that are not.
+	ListByStory(ctx context.Context, storyID, offset, limit int) ([]models.Comment, int, error)
+	// UpdateBody replaces the text of a comment, edited at the given time.
+	UpdateBody(ctx context.Context, id int, body string, at time.Time) error
+	// Delete marks a comment deleted and erases its text; its replies
+	// stay.
+	Delete(ctx context.Context, id int, at time.Time) error
+	// DeleteByUser deletes every comment of userID the way Delete does.
+	DeleteByUser(ctx context.Context, userID int, at time.Time) error
+	// SetHiddenAt hides a comment, or shows it again when at is nil.
+	SetHiddenAt(ctx context.Context, id int, at *time.Time) error
+}
+
+// Searcher finds published stories and accounts by the words they
+// contain, best match first.
+type Searcher interface {
+	// SearchStories returns at most limit published stories of enabled
+	// accounts matching query.
+	SearchStories(ctx context.Context, query string, limit int) ([]models.StorySearchHit, error)
+	// SearchUsers returns at most limit accounts whose name or email
+	// matches query.
+	SearchUsers(ctx context.Context, query string, limit int) ([]models.UserSearchHit, error)
+	// Reindex rebuilds what the searcher searches from the stored
+	// stories and accounts, and returns how many stories it indexed.
+	Reindex(ctx context.Context) (int, error)
+}
+
+// Repositories bundles every repository a handler needs.
+type Repositories struct {
+	Users          UserRepository
+	Stories        StoryRepository
+	Tags           TagRepository
+	Comments       CommentRepository
+	Tokens         TokenRepository
+	PasswordResets PasswordResetRepository
+	Search         Searcher
+}
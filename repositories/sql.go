@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Supported database drivers, named after the database/sql driver they
+// register.
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// sqlitePragmas are applied to every SQLite connection: foreign keys are
+// off by default in SQLite, WAL lets readers work alongside a writer and
+// the busy timeout makes concurrent writers wait instead of failing.
+var sqlitePragmas = []string{
+	"foreign_keys(1)",
+	"journal_mode(WAL)",
+	"busy_timeout(5000)",
+}
+
+// Open opens a connection pool for the given driver and checks that the
+// database is reachable.
+//
+// For MySQL parseTime is enabled on the DSN, so DATETIME columns scan
+// into time.Time. For SQLite the DSN is a file name or a "file:" URI; the
+// pragmas the repositories rely on are added to it, and times are
+// written in SQLite's own format so they sort as text.
+func Open(driver, dsn string) (*sql.DB, error) {
+	switch driver {
+	case DriverMySQL:
+		var err error
+		if dsn, err = mysqlDSN(dsn); err != nil {
+			return nil, err
+		}
+	case DriverSQLite:
+		dsn = sqliteDSN(dsn)
+	default:
+		return nil, fmt.Errorf("repositories: unsupported database driver %q", driver)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	// An in-memory SQLite database only lives as long as its connection,
+	// so the pool must never open a second one.
+	if driver == DriverSQLite && strings.Contains(dsn, ":memory:") {
+		db.SetMaxOpenConns(1)
+	}
+	// Ping the database to verify that the connection is working
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// New returns SQL repositories for a pool opened with Open.
+//
+// MySQL is searched with its FULLTEXT indexes; SQLite databases are
+// searched through an index kept in process memory.
+func New(db *sql.DB) *Repositories {
+	driver := driverOf(db)
+	repos := &Repositories{
+		Users:          &sqlUserRepository{db: db},
+		Stories:        &sqlStoryRepository{db: db, driver: driver},
+		Tags:           &sqlTagRepository{db: db},
+		Comments:       &sqlCommentRepository{db: db},
+		Tokens:         &sqlTokenRepository{db: db},
+		PasswordResets: &sqlPasswordResetRepository{db: db},
+	}
+	if driver == DriverSQLite {
+		return withSearchIndex(repos)
+	}
+	repos.Search = &mysqlSearcher{db: db}
+	return repos
+}
+
+// mysqlDSN enables parseTime on a MySQL DSN, which the repositories
+// need to scan DATETIME columns into time.Time.
+func mysqlDSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("repositories: invalid mysql dsn: %w", err)
+	}
+	cfg.ParseTime = true
+	return cfg.FormatDSN(), nil
+}
+
+// sqliteDSN adds the connection pragmas and the time format to a SQLite
+// DSN, leaving any pragma the DSN already sets alone.
+//
+// Without a time format the driver writes times with time.Time.String,
+// which neither sorts as text nor matches the times SQL writes itself.
+// With it, times written in UTC look like "2006-01-02 15:04:05+00:00".
+func sqliteDSN(dsn string) string {
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	for _, pragma := range sqlitePragmas {
+		name := pragma[:strings.Index(pragma, "(")]
+		if strings.Contains(dsn, "_pragma="+name) {
+			continue
+		}
+		dsn += separator + "_pragma=" + pragma
+		separator = "&"
+	}
+	if !strings.Contains(dsn, "_time_format=") {
+		dsn += separator + "_time_format=sqlite"
+	}
+	return dsn
+}
+
+// driverOf returns which of the supported drivers db was opened with.
+func driverOf(db *sql.DB) string {
+	if _, ok := db.Driver().(*sqlite.Driver); ok {
+		return DriverSQLite
+	}
+	return DriverMySQL
+}
+
+// isDuplicateKey reports whether err is a unique constraint violation.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+			sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// notFound translates sql.ErrNoRows into ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
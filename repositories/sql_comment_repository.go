@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"blog_project.com/models"
+)
+
+// sqlCommentRepository implements CommentRepository on MySQL and SQLite.
+type sqlCommentRepository struct {
+	db *sql.DB
+}
+
+// commentColumns are the columns scanComment reads from story_comments
+// aliased c joined with the users aliased u, in order. The join must be a
+// LEFT JOIN, as the author of a comment may have been deleted.
+const commentColumns = "c.id, c.story_id, c.parent_id, c.user_id, c.body, c.created_at, c.edited_at, c.deleted_at, c.hidden_at, u.full_name, u.profile_pic"
+
+// shownComment is the condition keeping the comments of story_comments
+// aliased c that are neither deleted nor hidden, or that are top-level
+// comments with replies that are neither.
+const shownComment = `((c.deleted_at IS NULL AND c.hidden_at IS NULL) OR EXISTS (
+	SELECT 1 FROM story_comments r WHERE r.parent_id = c.id AND r.deleted_at IS NULL AND r.hidden_at IS NULL))`
+
+func (r *sqlCommentRepository) Create(ctx context.Context, comment models.Comment) (int, error) {
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO story_comments (story_id, parent_id, user_id, body, created_at) VALUES (?, ?, ?, ?, ?)",
+		comment.StoryID, nullInt(comment.ParentID), comment.UserID, comment.Body, comment.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *sqlCommentRepository) FindByID(ctx context.Context, id int) (models.Comment, error) {
+	comment, err := scanComment(r.db.QueryRowContext(ctx,
+		"SELECT "+commentColumns+" FROM story_comments c LEFT JOIN users u ON u.id = c.user_id WHERE c.id = ?",
+		id,
+	))
+	return comment, notFound(err)
+}
+
+// ListByStory reads the page of top-level comments first, then the
+// replies of all of them at once.
+func (r *sqlCommentRepository) ListByStory(ctx context.Context, storyID, offset, limit int) ([]models.Comment, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM story_comments c WHERE c.story_id = ? AND c.parent_id IS NULL AND "+shownComment,
+		storyID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	comments, err := r.findAll(ctx,
+		"SELECT "+commentColumns+` FROM story_comments c LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.story_id = ? AND c.parent_id IS NULL AND `+shownComment+`
+		ORDER BY c.id LIMIT ? OFFSET ?`,
+		storyID, limit, offset,
+	)
+	if err != nil || len(comments) == 0 {
+		return comments, total, err
+	}
+
+	args := make([]interface{}, len(comments))
+	byID := map[int]int{}
+	for i, comment := range comments {
+		args[i] = comment.ID
+		byID[comment.ID] = i
+	}
+	replies, err := r.findAll(ctx,
+		"SELECT "+commentColumns+` FROM story_comments c LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.parent_id IN (?`+strings.Repeat(", ?", len(comments)-1)+`) AND c.deleted_at IS NULL AND c.hidden_at IS NULL
+		ORDER BY c.id`,
+		args...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, reply := range replies {
+		parent := &comments[byID[reply.ParentID]]
+		parent.Replies = append(parent.Replies, reply)
+	}
+	return comments, total, nil
+}
+
+func (r *sqlCommentRepository) UpdateBody(ctx context.Context, id int, body string, at time.Time) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE story_comments SET body = ?, edited_at = ? WHERE id = ?", body, at.UTC(), id)
+	return r.updated(ctx, id, result, err)
+}
+
+func (r *sqlCommentRepository) Delete(ctx context.Context, id int, at time.Time) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE story_comments SET body = '', deleted_at = ? WHERE id = ?", at.UTC(), id)
+	return r.updated(ctx, id, result, err)
+}
+
+func (r *sqlCommentRepository) DeleteByUser(ctx context.Context, userID int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE story_comments SET body = '', deleted_at = ? WHERE user_id = ? AND deleted_at IS NULL",
+		at.UTC(), userID,
+	)
+	return err
+}
+
+func (r *sqlCommentRepository) SetHiddenAt(ctx context.Context, id int, at *time.Time) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE story_comments SET hidden_at = ? WHERE id = ?", at, id)
+	return r.updated(ctx, id, result, err)
+}
+
+// updated turns an UPDATE of comment id that changed nothing into
+// ErrNotFound when the comment does not exist.
+func (r *sqlCommentRepository) updated(ctx context.Context, id int, result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+	var exists int
+	return notFound(r.db.QueryRowContext(ctx, "SELECT 1 FROM story_comments WHERE id = ?", id).Scan(&exists))
+}
+
+func (r *sqlCommentRepository) findAll(ctx context.Context, query string, args ...interface{}) ([]models.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// scanComment reads a row selected with commentColumns.
+func scanComment(row interface{ Scan(...interface{}) error }) (models.Comment, error) {
+	var comment models.Comment
+	var parentID, userID sql.NullInt64
+	var editedAt, deletedAt, hiddenAt sql.NullTime
+	var authorName, authorPic sql.NullString
+	err := row.Scan(&comment.ID, &comment.StoryID, &parentID, &userID, &comment.Body, &comment.CreatedAt,
+		&editedAt, &deletedAt, &hiddenAt, &authorName, &authorPic)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	comment.ParentID, comment.UserID = int(parentID.Int64), int(userID.Int64)
+	comment.AuthorName, comment.AuthorProfilePic = authorName.String, authorPic.String
+	if editedAt.Valid {
+		comment.EditedAt = &editedAt.Time
+	}
+	if deletedAt.Valid {
+		comment.DeletedAt = &deletedAt.Time
+	}
+	if hiddenAt.Valid {
+		comment.HiddenAt = &hiddenAt.Time
+	}
+	return comment, nil
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"blog_project.com/models"
+)
+
+// sqlPasswordResetRepository implements PasswordResetRepository on MySQL
+// and SQLite.
+type sqlPasswordResetRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlPasswordResetRepository) Create(ctx context.Context, token models.PasswordResetToken) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?)",
+		token.UserID, token.TokenHash, token.ExpiresAt.UTC(), token.CreatedAt.UTC(),
+	)
+	return err
+}
+
+func (r *sqlPasswordResetRepository) FindByHash(ctx context.Context, tokenHash string) (models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM password_reset_tokens WHERE token_hash = ?",
+		tokenHash,
+	).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.CreatedAt, &usedAt)
+	if err != nil {
+		return models.PasswordResetToken{}, notFound(err)
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	return token, nil
+}
+
+func (r *sqlPasswordResetRepository) MarkUsed(ctx context.Context, id int, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL",
+		at.UTC(), id,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *sqlPasswordResetRepository) InvalidateUser(ctx context.Context, userID int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		at.UTC(), userID,
+	)
+	return err
+}
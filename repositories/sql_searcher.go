@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"blog_project.com/models"
+	"blog_project.com/search"
+)
+
+// mysqlSearcher implements Searcher with MySQL FULLTEXT indexes in
+// natural language mode: on the plain text of every story, kept in the
+// search_text column by the story repository, and on the name and email
+// of every user.
+type mysqlSearcher struct {
+	db *sql.DB
+}
+
+// reindexBatchSize is how many stories Reindex reads at a time.
+const reindexBatchSize = 500
+
+func (s *mysqlSearcher) SearchStories(ctx context.Context, query string, limit int) ([]models.StorySearchHit, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT s.id, s.userId, s.status, s.story_type, s.stories, s.created_at, `+commentCount+`,
+			(SELECT COUNT(*) FROM story_likes l WHERE l.story_id = s.id) AS likes,
+			u.full_name, u.profile_pic,
+			MATCH (s.search_text) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+		FROM usersStory s JOIN users u ON u.id = s.userId
+		WHERE s.status = ? AND s.stories IS NOT NULL AND u.disabled_at IS NULL AND u.deleted_at IS NULL
+			AND MATCH (s.search_text) AGAINST (? IN NATURAL LANGUAGE MODE)
+		ORDER BY score DESC, s.id DESC
+		LIMIT ?`,
+		query, models.StoryPublished, query, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []models.StorySearchHit
+	for rows.Next() {
+		var hit models.StorySearchHit
+		if hit.Story, err = scanPublicStory(rows, &hit.Score); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func (s *mysqlSearcher) SearchUsers(ctx context.Context, query string, limit int) ([]models.UserSearchHit, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+userColumns+`, MATCH (full_name, email) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+		FROM users
+		WHERE MATCH (full_name, email) AGAINST (? IN NATURAL LANGUAGE MODE)
+		ORDER BY score DESC, id DESC
+		LIMIT ?`,
+		query, query, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []models.UserSearchHit
+	for rows.Next() {
+		var hit models.UserSearchHit
+		if hit.User, err = scanUser(rows, &hit.Score); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// Reindex fills search_text again for every story, for stories written
+// before the column existed or after the text extraction changed. The
+// FULLTEXT indexes follow by themselves.
+func (s *mysqlSearcher) Reindex(ctx context.Context) (int, error) {
+	count, lastID := 0, 0
+	for {
+		rows, err := s.db.QueryContext(ctx,
+			"SELECT id, stories FROM usersStory WHERE id > ? AND stories IS NOT NULL ORDER BY id LIMIT ?",
+			lastID, reindexBatchSize,
+		)
+		if err != nil {
+			return count, err
+		}
+		texts := map[int]string{}
+		var ids []int
+		for rows.Next() {
+			var id int
+			var data sql.NullString
+			if err := rows.Scan(&id, &data); err != nil {
+				rows.Close()
+				return count, err
+			}
+			content, err := decodeStory(data)
+			if err != nil {
+				rows.Close()
+				return count, err
+			}
+			texts[id] = search.DocumentText(content)
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return count, err
+		}
+		if len(ids) == 0 {
+			return count, nil
+		}
+
+		for _, id := range ids {
+			if _, err := s.db.ExecContext(ctx, "UPDATE usersStory SET search_text = ? WHERE id = ?", texts[id], id); err != nil {
+				return count, err
+			}
+		}
+		count += len(ids)
+		lastID = ids[len(ids)-1]
+	}
+}
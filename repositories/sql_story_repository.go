@@ -0,0 +1,630 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"blog_project.com/models"
+	"blog_project.com/search"
+)
+
+// sqlStoryRepository implements StoryRepository on MySQL and SQLite.
+//
+// Story documents are written as JSON text so both the MySQL JSON column
+// and the SQLite TEXT column accept them.
+type sqlStoryRepository struct {
+	db     *sql.DB
+	driver string
+}
+
+// storyColumns are the columns findAll reads from usersStory aliased s,
+// in order.
+const storyColumns = "id, userId, status, story_type, stories, created_at, " + commentCount
+
+// commentCount counts the comments shown on the story aliased s.
+const commentCount = "(SELECT COUNT(*) FROM story_comments c WHERE c.story_id = s.id AND c.deleted_at IS NULL AND c.hidden_at IS NULL)"
+
+// Create stores created_at with whole seconds: SQLite compares the
+// DATETIME text the driver writes, which only sorts correctly when every
+// value has the same precision.
+func (r *sqlStoryRepository) Create(ctx context.Context, userID int, storyType string, content map[string]interface{}, tags []string) (int, error) {
+	storyJSON, hash, err := encodeRevision(content)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO usersStory (stories, story_type, search_text, userId, created_at) VALUES (?, ?, ?, ?, ?)",
+		storyJSON, storyType, search.DocumentText(content), userID, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	err = insertRevision(ctx, tx, models.StoryRevision{
+		StoryID:     int(id),
+		Revision:    1,
+		Type:        storyType,
+		ContentHash: hash,
+		AuthorID:    userID,
+		CreatedAt:   now,
+	}, storyJSON)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.setTags(ctx, tx, int(id), tags, now); err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+func (r *sqlStoryRepository) FindByID(ctx context.Context, id int) (models.Story, error) {
+	story, err := scanStory(r.db.QueryRowContext(ctx, "SELECT "+storyColumns+" FROM usersStory s WHERE id = ?", id))
+	if err != nil && !errors.Is(err, errNoDocument) {
+		return story, notFound(err)
+	}
+	tags, err := r.tagNames(ctx, []int{story.ID})
+	story.Tags = tags[story.ID]
+	return story, err
+}
+
+func (r *sqlStoryRepository) ListByUser(ctx context.Context, userID int) ([]models.Story, error) {
+	return r.findAll(ctx, "SELECT "+storyColumns+" FROM usersStory s WHERE userId = ?", userID)
+}
+
+// CountByUser counts the same rows ListByUser returns.
+func (r *sqlStoryRepository) CountByUser(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM usersStory WHERE userId = ? AND stories IS NOT NULL", userID,
+	).Scan(&count)
+	return count, err
+}
+
+// setTags replaces the tags of a story with the named ones within tx.
+// It looks every tag up before creating it at the given time, and reads
+// it again if a concurrent write created it first.
+func (r *sqlStoryRepository) setTags(ctx context.Context, tx *sql.Tx, storyID int, names []string, at time.Time) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM story_tags WHERE story_id = ?", storyID); err != nil {
+		return err
+	}
+	for _, name := range names {
+		tagID, err := r.findOrCreateTag(ctx, tx, name, at)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO story_tags (story_id, tag_id) VALUES (?, ?)", storyID, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findOrCreateTag returns the ID of the tag called name within tx,
+// creating the tag at the given time if there is none.
+func (r *sqlStoryRepository) findOrCreateTag(ctx context.Context, tx *sql.Tx, name string, at time.Time) (int, error) {
+	var id int
+	lookup := "SELECT id FROM tags WHERE name = ?" + r.forUpdate()
+	err := tx.QueryRowContext(ctx, lookup, name).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	result, err := tx.ExecContext(ctx, "INSERT INTO tags (name, created_at) VALUES (?, ?)", name, at)
+	if isDuplicateKey(err) {
+		err = tx.QueryRowContext(ctx, lookup, name).Scan(&id)
+		return id, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	created, err := result.LastInsertId()
+	return int(created), err
+}
+
+// Update locks the story row on MySQL so concurrent writes number their
+// revisions one after the other.
+func (r *sqlStoryRepository) Update(ctx context.Context, rev models.StoryRevision, tags []string, t *models.StoryTransition) (models.StoryRevision, error) {
+	storyJSON, hash, err := encodeRevision(rev.Content)
+	if err != nil {
+		return rev, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return rev, err
+	}
+	defer tx.Rollback()
+
+	var current models.Story
+	var currentData sql.NullString
+	var createdAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		"SELECT userId, story_type, stories, created_at FROM usersStory WHERE id = ?"+r.forUpdate(), rev.StoryID,
+	).Scan(&current.UserID, &current.Type, &currentData, &createdAt)
+	if err != nil {
+		return rev, notFound(err)
+	}
+	var last int
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(revision), 0) FROM story_revisions WHERE story_id = ?", rev.StoryID).Scan(&last)
+	if err != nil {
+		return rev, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if last == 0 && currentData.Valid {
+		// The story predates revisions: keep the version being replaced
+		// as its first revision, credited to the story's owner.
+		if current.Content, err = decodeStory(currentData); err != nil {
+			return rev, err
+		}
+		baselineJSON, baselineHash, err := encodeRevision(current.Content)
+		if err != nil {
+			return rev, err
+		}
+		baseline := models.StoryRevision{
+			StoryID:     rev.StoryID,
+			Revision:    1,
+			Type:        current.Type,
+			ContentHash: baselineHash,
+			AuthorID:    current.UserID,
+			CreatedAt:   createdAt.Time.UTC(),
+		}
+		if !createdAt.Valid {
+			baseline.CreatedAt = now
+		}
+		if err := insertRevision(ctx, tx, baseline, baselineJSON); err != nil {
+			return rev, err
+		}
+		last = 1
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE usersStory SET stories = ?, story_type = ?, search_text = ? WHERE id = ?",
+		storyJSON, rev.Type, search.DocumentText(rev.Content), rev.StoryID,
+	)
+	if err != nil {
+		return rev, err
+	}
+	rev.Revision, rev.ContentHash, rev.CreatedAt = last+1, hash, now
+	if err := insertRevision(ctx, tx, rev, storyJSON); err != nil {
+		return rev, err
+	}
+	if tags != nil {
+		if err := r.setTags(ctx, tx, rev.StoryID, tags, now); err != nil {
+			return rev, err
+		}
+	}
+	if t != nil {
+		moved, err := transition(ctx, tx, *t)
+		if err != nil {
+			return rev, err
+		}
+		if !moved {
+			return rev, ErrStatusChanged
+		}
+	}
+	return rev, tx.Commit()
+}
+
+func (r *sqlStoryRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM usersStory WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+	return ErrNotFound
+}
+
+func (r *sqlStoryRepository) DeleteByUser(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM usersStory WHERE userId = ?", userID)
+	return err
+}
+
+func (r *sqlStoryRepository) ListByStatus(ctx context.Context, status string, offset, limit int) ([]models.Story, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM usersStory WHERE status = ? AND stories IS NOT NULL", status,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	stories, err := r.findAll(ctx,
+		"SELECT "+storyColumns+" FROM usersStory s WHERE status = ? AND stories IS NOT NULL ORDER BY id LIMIT ? OFFSET ?",
+		status, limit, offset,
+	)
+	return stories, total, err
+}
+
+func (r *sqlStoryRepository) Transition(ctx context.Context, t models.StoryTransition) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	moved, err := transition(ctx, tx, t)
+	if err != nil || !moved {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// transition moves a story from t.From to t.To and records t within tx.
+// It returns false, changing nothing, when the story's status is no
+// longer t.From.
+func transition(ctx context.Context, tx *sql.Tx, t models.StoryTransition) (bool, error) {
+	result, err := tx.ExecContext(ctx, "UPDATE usersStory SET status = ? WHERE id = ? AND status = ?", t.To, t.StoryID, t.From)
+	if err != nil {
+		return false, err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO story_status_transitions (story_id, from_status, to_status, actor_id, reason, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		t.StoryID, t.From, t.To, nullInt(t.ActorID), nullString(t.Reason), t.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *sqlStoryRepository) ListTransitions(ctx context.Context, storyID int) ([]models.StoryTransition, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, story_id, from_status, to_status, actor_id, reason, created_at FROM story_status_transitions WHERE story_id = ? ORDER BY id",
+		storyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transitions []models.StoryTransition
+	for rows.Next() {
+		var t models.StoryTransition
+		var actorID sql.NullInt64
+		var reason sql.NullString
+		if err := rows.Scan(&t.ID, &t.StoryID, &t.From, &t.To, &actorID, &reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		t.ActorID, t.Reason = int(actorID.Int64), reason.String
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}
+
+// ListPublished counts likes with a subquery rather than keeping a
+// counter on the story, so deleting a user's likes along with the user
+// can never leave it wrong. The derived table lets the cursor condition
+// compare against that count.
+func (r *sqlStoryRepository) ListPublished(ctx context.Context, q models.StoryFeedQuery) ([]models.PublicStory, error) {
+	filters := []string{
+		"s.status = ?",
+		"s.stories IS NOT NULL",
+		"u.disabled_at IS NULL",
+		"u.deleted_at IS NULL",
+	}
+	args := []interface{}{models.StoryPublished}
+	if q.AuthorID != 0 {
+		filters = append(filters, "s.userId = ?")
+		args = append(args, q.AuthorID)
+	}
+	if q.Tag != "" {
+		// The collation of tags.name makes the comparison ignore case
+		filters = append(filters, "EXISTS (SELECT 1 FROM story_tags st JOIN tags g ON g.id = st.tag_id WHERE st.story_id = s.id AND g.name = ?)")
+		args = append(args, q.Tag)
+	}
+
+	order := "created_at DESC, id DESC"
+	var after string
+	if q.Sort == models.StorySortMostLiked {
+		order = "likes DESC, " + order
+		if q.After != nil {
+			after = " WHERE likes < ? OR (likes = ? AND (created_at < ? OR (created_at = ? AND id < ?)))"
+			args = append(args, q.After.Likes, q.After.Likes, q.After.CreatedAt.UTC(), q.After.CreatedAt.UTC(), q.After.ID)
+		}
+	} else if q.After != nil {
+		after = " WHERE created_at < ? OR (created_at = ? AND id < ?)"
+		args = append(args, q.After.CreatedAt.UTC(), q.After.CreatedAt.UTC(), q.After.ID)
+	}
+	args = append(args, q.Limit)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, userId, status, story_type, stories, created_at, comments, likes, full_name, profile_pic FROM (
+			SELECT s.id, s.userId, s.status, s.story_type, s.stories, s.created_at, `+commentCount+` AS comments,
+				(SELECT COUNT(*) FROM story_likes l WHERE l.story_id = s.id) AS likes,
+				u.full_name, u.profile_pic
+			FROM usersStory s JOIN users u ON u.id = s.userId
+			WHERE `+strings.Join(filters, " AND ")+`
+		) feed`+after+`
+		ORDER BY `+order+`
+		LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []models.PublicStory
+	var ids []int
+	for rows.Next() {
+		story, err := scanPublicStory(rows)
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+		ids = append(ids, story.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tags, err := r.tagNames(ctx, ids)
+	for i := range stories {
+		stories[i].Tags = tags[stories[i].ID]
+	}
+	return stories, err
+}
+
+func (r *sqlStoryRepository) ListRevisions(ctx context.Context, storyID int) ([]models.StoryRevision, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+revisionColumns+" FROM story_revisions WHERE story_id = ? ORDER BY revision",
+		storyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.StoryRevision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *sqlStoryRepository) FindRevision(ctx context.Context, storyID, revision int) (models.StoryRevision, error) {
+	var data sql.NullString
+	rev, err := scanRevision(r.db.QueryRowContext(ctx,
+		"SELECT "+revisionColumns+", stories FROM story_revisions WHERE story_id = ? AND revision = ?",
+		storyID, revision,
+	), &data)
+	if err != nil {
+		return rev, notFound(err)
+	}
+	rev.Content, err = decodeStory(data)
+	return rev, err
+}
+
+// forUpdate returns the clause locking the rows a SELECT reads until the
+// end of the transaction. SQLite has none; it locks the whole database
+// for writes instead.
+func (r *sqlStoryRepository) forUpdate() string {
+	if r.driver == DriverSQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+func (r *sqlStoryRepository) Like(ctx context.Context, storyID, userID int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO story_likes (story_id, user_id, created_at) VALUES (?, ?, ?)",
+		storyID, userID, at.UTC(),
+	)
+	if isDuplicateKey(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *sqlStoryRepository) Unlike(ctx context.Context, storyID, userID int) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM story_likes WHERE story_id = ? AND user_id = ?", storyID, userID)
+	return err
+}
+
+func (r *sqlStoryRepository) CountLikes(ctx context.Context, storyID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM story_likes WHERE story_id = ?", storyID).Scan(&count)
+	return count, err
+}
+
+// findAll reads the stories selected by query, which must select
+// storyColumns. Rows without a document are skipped, as they always were.
+func (r *sqlStoryRepository) findAll(ctx context.Context, query string, args ...interface{}) ([]models.Story, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []models.Story
+	var ids []int
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if errors.Is(err, errNoDocument) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+		ids = append(ids, story.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Free the connection first: in-memory SQLite databases only have one
+	rows.Close()
+
+	tags, err := r.tagNames(ctx, ids)
+	for i := range stories {
+		stories[i].Tags = tags[stories[i].ID]
+	}
+	return stories, err
+}
+
+// tagNames returns the names of the tags of the given stories by story
+// ID, in alphabetical order.
+func (r *sqlStoryRepository) tagNames(ctx context.Context, storyIDs []int) (map[int][]string, error) {
+	if len(storyIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(storyIDs))
+	for i, id := range storyIDs {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT st.story_id, g.name FROM story_tags st JOIN tags g ON g.id = st.tag_id WHERE st.story_id IN (?"+
+			strings.Repeat(", ?", len(storyIDs)-1)+") ORDER BY g.name",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[int][]string{}
+	for rows.Next() {
+		var storyID int
+		var name string
+		if err := rows.Scan(&storyID, &name); err != nil {
+			return nil, err
+		}
+		names[storyID] = append(names[storyID], name)
+	}
+	return names, rows.Err()
+}
+
+// errNoDocument is returned by scanStory for rows whose stories column
+// is NULL, which list queries skip.
+var errNoDocument = errors.New("repositories: story has no document")
+
+// scanStory reads a row selected with storyColumns. The story is
+// returned along with errNoDocument when its document is NULL.
+func scanStory(row interface{ Scan(...interface{}) error }) (models.Story, error) {
+	var story models.Story
+	var storyData sql.NullString
+	var createdAt sql.NullTime
+	if err := row.Scan(&story.ID, &story.UserID, &story.Status, &story.Type, &storyData, &createdAt, &story.Comments); err != nil {
+		return models.Story{}, err
+	}
+	story.CreatedAt = createdAt.Time
+	content, err := decodeStory(storyData)
+	if err != nil {
+		return models.Story{}, err
+	}
+	story.Content = content
+	if !storyData.Valid {
+		return story, errNoDocument
+	}
+	return story, nil
+}
+
+// scanPublicStory reads a row selecting a story's id, userId, status,
+// story_type, stories and created_at, its commentCount and likes and its
+// author's full_name and profile_pic, followed by the columns scanned
+// into extra.
+func scanPublicStory(row interface{ Scan(...interface{}) error }, extra ...interface{}) (models.PublicStory, error) {
+	var story models.PublicStory
+	var storyData sql.NullString
+	dest := []interface{}{&story.ID, &story.UserID, &story.Status, &story.Type, &storyData, &story.CreatedAt,
+		&story.Comments, &story.Likes, &story.AuthorName, &story.AuthorProfilePic}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return models.PublicStory{}, err
+	}
+	content, err := decodeStory(storyData)
+	story.Content = content
+	return story, err
+}
+
+// revisionColumns are the columns scanRevision reads, in order. The
+// document is not among them, as listing revisions leaves it out.
+const revisionColumns = "story_id, revision, story_type, content_hash, author_id, restored_from, created_at"
+
+// scanRevision reads a row selected with revisionColumns, followed by
+// the columns scanned into extra.
+func scanRevision(row interface{ Scan(...interface{}) error }, extra ...interface{}) (models.StoryRevision, error) {
+	var rev models.StoryRevision
+	var authorID, restoredFrom sql.NullInt64
+	dest := append([]interface{}{&rev.StoryID, &rev.Revision, &rev.Type, &rev.ContentHash, &authorID, &restoredFrom, &rev.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return models.StoryRevision{}, err
+	}
+	rev.AuthorID, rev.RestoredFrom = int(authorID.Int64), int(restoredFrom.Int64)
+	return rev, nil
+}
+
+// insertRevision records rev, whose document is storyJSON, within tx.
+func insertRevision(ctx context.Context, tx *sql.Tx, rev models.StoryRevision, storyJSON string) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO story_revisions (story_id, revision, story_type, stories, content_hash, author_id, restored_from, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		rev.StoryID, rev.Revision, rev.Type, storyJSON, rev.ContentHash, nullInt(rev.AuthorID), nullInt(rev.RestoredFrom), rev.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// nullInt stores 0 as NULL.
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
+// nullString stores "" as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// decodeStory unmarshals the JSON stories column, treating NULL as an
+// empty document.
+func decodeStory(data sql.NullString) (map[string]interface{}, error) {
+	content := map[string]interface{}{}
+	if !data.Valid {
+		return content, nil
+	}
+	if err := json.Unmarshal([]byte(data.String), &content); err != nil {
+		return nil, err
+	}
+	if content == nil {
+		content = map[string]interface{}{}
+	}
+	return content, nil
+}
+
+// encodeRevision returns the JSON text stored for a story document and
+// the hex SHA-256 of it. encoding/json writes object members in key
+// order, so equal documents always hash the same.
+func encodeRevision(content map[string]interface{}) (string, string, error) {
+	if content == nil {
+		content = map[string]interface{}{}
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return string(data), hex.EncodeToString(sum[:]), nil
+}
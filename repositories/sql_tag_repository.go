@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"blog_project.com/models"
+)
+
+// sqlTagRepository implements TagRepository on MySQL and SQLite. The
+// collation of tags.name makes names compare regardless of case.
+type sqlTagRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlTagRepository) List(ctx context.Context, published bool) ([]models.Tag, error) {
+	query := `SELECT g.id, g.name, g.created_at, COUNT(st.story_id)
+		FROM tags g LEFT JOIN story_tags st ON st.tag_id = g.id
+		GROUP BY g.id, g.name, g.created_at
+		ORDER BY g.name`
+	var args []interface{}
+	if published {
+		query = `SELECT g.id, g.name, g.created_at, COUNT(*)
+			FROM tags g
+			JOIN story_tags st ON st.tag_id = g.id
+			JOIN usersStory s ON s.id = st.story_id
+			JOIN users u ON u.id = s.userId
+			WHERE s.status = ? AND s.stories IS NOT NULL AND u.disabled_at IS NULL AND u.deleted_at IS NULL
+			GROUP BY g.id, g.name, g.created_at
+			ORDER BY g.name`
+		args = append(args, models.StoryPublished)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (r *sqlTagRepository) FindByID(ctx context.Context, id int) (models.Tag, error) {
+	tag, err := scanTag(r.db.QueryRowContext(ctx,
+		"SELECT id, name, created_at, (SELECT COUNT(*) FROM story_tags WHERE tag_id = tags.id) FROM tags WHERE id = ?",
+		id,
+	))
+	return tag, notFound(err)
+}
+
+func (r *sqlTagRepository) Rename(ctx context.Context, id int, name string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE tags SET name = ? WHERE id = ?", name, id)
+	if isDuplicateKey(err) {
+		return ErrDuplicateTag
+	}
+	if err != nil {
+		return err
+	}
+	// MySQL reports no affected row when the name does not change
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+	var exists int
+	return notFound(r.db.QueryRowContext(ctx, "SELECT 1 FROM tags WHERE id = ?", id).Scan(&exists))
+}
+
+// Merge tags the stories of from that into does not already carry, then
+// lets deleting from cascade to its links.
+func (r *sqlTagRepository) Merge(ctx context.Context, from, into int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM tags WHERE id IN (?, ?)", from, into).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if found != 2 {
+		return ErrNotFound
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO story_tags (story_id, tag_id)
+		SELECT story_id, ? FROM story_tags
+		WHERE tag_id = ? AND story_id NOT IN (SELECT story_id FROM story_tags WHERE tag_id = ?)`,
+		into, from, into,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id = ?", from); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// scanTag reads a row selecting a tag's id, name and created_at followed
+// by how many stories carry it.
+func scanTag(row interface{ Scan(...interface{}) error }) (models.Tag, error) {
+	var tag models.Tag
+	err := row.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.Stories)
+	return tag, err
+}
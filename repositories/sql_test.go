@@ -0,0 +1,24 @@
+package repositories
+
+import "testing"
+
+func TestMySQLDSNEnablesParseTime(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"root:secret@tcp(localhost:3306)/blog", "root:secret@tcp(localhost:3306)/blog?parseTime=true"},
+		{"root:secret@tcp(localhost:3306)/blog?parseTime=false", "root:secret@tcp(localhost:3306)/blog?parseTime=true"},
+		{"root:secret@tcp(localhost:3306)/blog?parseTime=true&charset=utf8mb4", "root:secret@tcp(localhost:3306)/blog?parseTime=true&charset=utf8mb4"},
+	}
+	for _, tt := range tests {
+		got, err := mysqlDSN(tt.dsn)
+		if err != nil || got != tt.want {
+			t.Errorf("mysqlDSN(%q) = %q, %v, want %q", tt.dsn, got, err, tt.want)
+		}
+	}
+
+	if _, err := mysqlDSN("root:secret@localhost/blog"); err == nil {
+		t.Error("mysqlDSN accepted a DSN without a protocol around the address")
+	}
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"blog_project.com/models"
+)
+
+// sqlTokenRepository implements TokenRepository on MySQL and SQLite.
+type sqlTokenRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlTokenRepository) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt.UTC(), token.CreatedAt.UTC(),
+	)
+	return err
+}
+
+func (r *sqlTokenRepository) FindRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, family_id, token_hash, expires_at, created_at, used_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = ?`,
+		tokenHash,
+	).Scan(
+		&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash,
+		&token.ExpiresAt, &token.CreatedAt, &usedAt, &revokedAt,
+	)
+	if err != nil {
+		return models.RefreshToken{}, notFound(err)
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+func (r *sqlTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id int, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL",
+		at.UTC(), id,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *sqlTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL",
+		at.UTC(), familyID,
+	)
+	return err
+}
+
+func (r *sqlTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		at.UTC(), userID,
+	)
+	return err
+}
+
+func (r *sqlTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"REPLACE INTO revoked_tokens (jti, user_id, expires_at, revoked_at) VALUES (?, ?, ?, ?)",
+		tokenID, userID, expiresAt.UTC(), time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *sqlTokenRepository) RevokeUserAccessTokens(ctx context.Context, userID int, before, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"REPLACE INTO user_token_revocations (user_id, revoked_before, expires_at) VALUES (?, ?, ?)",
+		userID, before.UTC(), expiresAt.UTC(),
+	)
+	return err
+}
+
+func (r *sqlTokenRepository) PurgeExpiredRevocations(ctx context.Context, now time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= ?", now.UTC()); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, "DELETE FROM user_token_revocations WHERE expires_at <= ?", now.UTC())
+	return err
+}
+
+func (r *sqlTokenRepository) RevokedAccessTokens(ctx context.Context) (models.RevokedAccessTokens, error) {
+	revoked := models.RevokedAccessTokens{
+		Tokens: map[string]time.Time{},
+		Users:  map[int]time.Time{},
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT jti, expires_at FROM revoked_tokens")
+	if err != nil {
+		return revoked, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tokenID string
+		var expiresAt time.Time
+		if err := rows.Scan(&tokenID, &expiresAt); err != nil {
+			return revoked, err
+		}
+		revoked.Tokens[tokenID] = expiresAt
+	}
+	if err := rows.Err(); err != nil {
+		return revoked, err
+	}
+
+	userRows, err := r.db.QueryContext(ctx, "SELECT user_id, revoked_before FROM user_token_revocations")
+	if err != nil {
+		return revoked, err
+	}
+	defer userRows.Close()
+	for userRows.Next() {
+		var userID int
+		var revokedBefore time.Time
+		if err := userRows.Scan(&userID, &revokedBefore); err != nil {
+			return revoked, err
+		}
+		revoked.Users[userID] = revokedBefore
+	}
+	return revoked, userRows.Err()
+}
@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"blog_project.com/models"
+)
+
+// sqlUserRepository implements UserRepository on MySQL and SQLite.
+type sqlUserRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlUserRepository) Create(ctx context.Context, user models.RegisterUserModel) (int, error) {
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO users (full_name, email, password, profile_pic, role, email_verified_at) VALUES (?, ?, ?, ?, ?, ?)",
+		user.FullName, user.Email, user.Password, user.ProfilePic, user.Role, user.EmailVerifiedAt,
+	)
+	if isDuplicateKey(err) {
+		return 0, ErrDuplicateEmail
+	}
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// userColumns are the columns scanUser reads, in order.
+const userColumns = "id, full_name, email, profile_pic, password, deleted_at, email_verified_at, role, disabled_at"
+
+func (r *sqlUserRepository) FindByID(ctx context.Context, id int) (models.RegisterUserModel, error) {
+	return r.findOne(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id)
+}
+
+func (r *sqlUserRepository) FindByEmail(ctx context.Context, email string) (models.RegisterUserModel, error) {
+	return r.findOne(ctx, "SELECT "+userColumns+" FROM users WHERE email = ?", email)
+}
+
+func (r *sqlUserRepository) UpdateProfile(ctx context.Context, id int, fullName, email string) error {
+	// email_verified_at is assigned first because MySQL evaluates SET
+	// assignments left to right and must compare against the old email.
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET
+			email_verified_at = CASE WHEN email = ? THEN email_verified_at ELSE NULL END,
+			full_name = ?, email = ?
+		WHERE id = ?`,
+		email, fullName, email, id,
+	)
+	if isDuplicateKey(err) {
+		return ErrDuplicateEmail
+	}
+	return r.updated(ctx, id, result, err)
+}
+
+func (r *sqlUserRepository) UpdateProfilePic(ctx context.Context, id int, profilePic string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET profile_pic = ? WHERE id = ?", profilePic, id)
+	return r.updated(ctx, id, result, err)
+}
+
+func (r *sqlUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", passwordHash, id)
+	return r.updated(ctx, id, result, err)
+}
+
+func (r *sqlUserRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET role = ? WHERE id = ?", role, id)
+	return r.updated(ctx, id, result, err)
+}
+
+func (r *sqlUserRepository) MarkEmailVerified(ctx context.Context, id int, email string, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE users SET email_verified_at = COALESCE(email_verified_at, ?) WHERE id = ? AND email = ?",
+		at.UTC(), id, email,
+	)
+	if err != nil {
+		return false, err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return affected > 0, err
+	}
+	// Already verified rows are not changed on MySQL; look again.
+	var exists int
+	err = r.db.QueryRowContext(ctx, "SELECT 1 FROM users WHERE id = ? AND email = ?", id, email).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *sqlUserRepository) ClaimVerificationSend(ctx context.Context, id int, at, notBefore time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE users SET verification_sent_at = ? WHERE id = ? AND (verification_sent_at IS NULL OR verification_sent_at <= ?)",
+		at.UTC(), id, notBefore.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *sqlUserRepository) SetDeletedAt(ctx context.Context, id int, at *time.Time) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET deleted_at = ? WHERE id = ?", at, id)
+	return r.updated(ctx, id, result, err)
+}
+
+func (r *sqlUserRepository) ListDeletedBefore(ctx context.Context, before time.Time) ([]models.RegisterUserModel, error) {
+	return r.findAll(ctx,
+		"SELECT "+userColumns+" FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ? ORDER BY id",
+		before,
+	)
+}
+
+func (r *sqlUserRepository) Search(ctx context.Context, query string, offset, limit int) ([]models.RegisterUserModel, int, error) {
+	where, args := "", []interface{}{}
+	if query != "" {
+		pattern := "%" + escapeLike(query) + "%"
+		where = " WHERE full_name LIKE ? ESCAPE '!' OR email LIKE ? ESCAPE '!'"
+		args = append(args, pattern, pattern)
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	users, err := r.findAll(ctx,
+		"SELECT "+userColumns+" FROM users"+where+" ORDER BY id LIMIT ? OFFSET ?",
+		append(args, limit, offset)...,
+	)
+	return users, total, err
+}
+
+func (r *sqlUserRepository) SetDisabledAt(ctx context.Context, id int, at *time.Time) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET disabled_at = ? WHERE id = ?", at, id)
+	return r.updated(ctx, id, result, err)
+}
+
+func (r *sqlUserRepository) DisabledIDs(ctx context.Context) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM users WHERE disabled_at IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Delete relies on the ON DELETE CASCADE foreign keys to remove the
+// user's stories and refresh tokens.
+func (r *sqlUserRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+	return ErrNotFound
+}
+
+// updated checks the result of an UPDATE of user id. MySQL reports
+// changed rather than matched rows, so an update that writes identical
+// values affects nothing; tell it apart from a missing row with an
+// explicit lookup.
+func (r *sqlUserRepository) updated(ctx context.Context, id int, result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+	var exists int
+	return notFound(r.db.QueryRowContext(ctx, "SELECT 1 FROM users WHERE id = ?", id).Scan(&exists))
+}
+
+func (r *sqlUserRepository) findOne(ctx context.Context, query string, arg interface{}) (models.RegisterUserModel, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, arg))
+	return user, notFound(err)
+}
+
+func (r *sqlUserRepository) findAll(ctx context.Context, query string, args ...interface{}) ([]models.RegisterUserModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.RegisterUserModel
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// escapeLike escapes the LIKE wildcards in s, using ! as the escape
+// character.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// scanUser reads a row selected with userColumns, followed by the
+// columns scanned into extra.
+func scanUser(row interface{ Scan(...interface{}) error }, extra ...interface{}) (models.RegisterUserModel, error) {
+	var user models.RegisterUserModel
+	var deletedAt, verifiedAt, disabledAt sql.NullTime
+	dest := []interface{}{&user.ID, &user.FullName, &user.Email, &user.ProfilePic, &user.Password, &deletedAt, &verifiedAt, &user.Role, &disabledAt}
+	err := row.Scan(append(dest, extra...)...)
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	if verifiedAt.Valid {
+		user.EmailVerifiedAt = &verifiedAt.Time
+	}
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	return user, err
+}
@@ -3,36 +3,116 @@ package routers
 import (
 	"net/http"
 
-	"blog_project.com/controllers" // Replace with your actual package path
+	"blog_project.com/config"
+	"blog_project.com/controllers"
+	"blog_project.com/models"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 )
 
 // SetupRouter initializes and returns a configured router with both API and static file routes.
-func SetupRouter() http.Handler {
+//
+// API requests are served by h; cross-origin access is configured from
+// the cors section of cfg. The probes served by health are mounted on
+// the root router, outside /api and the CORS wrapping.
+func SetupRouter(cfg *config.Config, h *controllers.Handler, health *controllers.HealthHandler) http.Handler {
 	// Create a new Gorilla Mux router
 	r := mux.NewRouter()
 
 	// API Routes
 	apiRouter := r.PathPrefix("/api").Subrouter()
-	apiRouter.HandleFunc("/register", controllers.CreateUser).Methods("POST")
-	apiRouter.HandleFunc("/login", controllers.LoginUser).Methods("POST")
-	apiRouter.HandleFunc("/profile", controllers.GetUserProfile).Methods("GET")
-	apiRouter.HandleFunc("/add-story", controllers.AddStory).Methods("POST")
-	apiRouter.HandleFunc("/get-story", controllers.GetStory).Methods("GET")
+	apiRouter.HandleFunc("/register", h.CreateUser).Methods("POST")
+	apiRouter.HandleFunc("/login", h.LoginUser).Methods("POST")
+	apiRouter.HandleFunc("/token/refresh", h.RefreshToken).Methods("POST")
+	apiRouter.HandleFunc("/password/forgot", h.ForgotPassword).Methods("POST")
+	apiRouter.HandleFunc("/password/reset", h.ResetPassword).Methods("POST")
+	apiRouter.HandleFunc("/verify-email", h.VerifyEmail).Methods("GET")
+	apiRouter.HandleFunc("/verify-email/resend", h.ResendVerification).Methods("POST")
+	apiRouter.HandleFunc("/public/stories", h.ListPublicStories).Methods("GET")
+	apiRouter.HandleFunc("/story-types", h.ListStoryTypes).Methods("GET")
+	apiRouter.HandleFunc("/tags", h.ListTags).Methods("GET")
+	apiRouter.HandleFunc("/stories/{id:[0-9]+}/comments", h.ListComments).Methods("GET")
 
-	// Static file handler for serving files from the "uploads" directory
-	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
+	// Routes that require a valid bearer token
+	protected := apiRouter.NewRoute().Subrouter()
+	protected.Use(controllers.AuthMiddleware)
+	protected.HandleFunc("/logout", h.Logout).Methods("POST")
+	protected.HandleFunc("/logout-all", h.LogoutAll).Methods("POST")
+	protected.HandleFunc("/password/change", h.ChangePassword).Methods("POST")
+	protected.HandleFunc("/profile", h.GetUserProfile).Methods("GET")
+	protected.HandleFunc("/profile", h.UpdateProfile).Methods("PATCH")
+	protected.HandleFunc("/profile", h.DeleteAccount).Methods("DELETE")
+	protected.HandleFunc("/profile/picture", h.UpdateProfilePicture).Methods("PUT")
+	protected.HandleFunc("/add-story", h.AddStory).Methods("POST")
+	protected.HandleFunc("/get-story", h.GetStory).Methods("GET")
+	protected.HandleFunc("/search", h.Search).Methods("GET")
+	protected.HandleFunc("/stories/{id:[0-9]+}", h.GetStoryByID).Methods("GET")
+	protected.HandleFunc("/stories/{id:[0-9]+}", h.UpdateStory).Methods("PUT")
+	protected.HandleFunc("/stories/{id:[0-9]+}", h.PatchStory).Methods("PATCH")
+	protected.HandleFunc("/stories/{id:[0-9]+}", h.DeleteStory).Methods("DELETE")
+	protected.HandleFunc("/stories/{id:[0-9]+}/submit", h.SubmitStory).Methods("POST")
+	protected.HandleFunc("/stories/{id:[0-9]+}/withdraw", h.WithdrawStory).Methods("POST")
+	protected.HandleFunc("/stories/{id:[0-9]+}/history", h.GetStoryHistory).Methods("GET")
+	protected.HandleFunc("/stories/{id:[0-9]+}/revisions", h.ListStoryRevisions).Methods("GET")
+	protected.HandleFunc("/stories/{id:[0-9]+}/revisions/diff", h.DiffStoryRevisions).Methods("GET")
+	protected.HandleFunc("/stories/{id:[0-9]+}/revisions/{revision:[0-9]+}", h.GetStoryRevision).Methods("GET")
+	protected.HandleFunc("/stories/{id:[0-9]+}/revisions/{revision:[0-9]+}/restore", h.RestoreStoryRevision).Methods("POST")
+	protected.HandleFunc("/stories/{id:[0-9]+}/like", h.LikeStory).Methods("PUT")
+	protected.HandleFunc("/stories/{id:[0-9]+}/like", h.UnlikeStory).Methods("DELETE")
+	protected.HandleFunc("/stories/{id:[0-9]+}/comments", h.AddComment).Methods("POST")
+	protected.HandleFunc("/comments/{id:[0-9]+}", h.UpdateComment).Methods("PATCH")
+	protected.HandleFunc("/comments/{id:[0-9]+}", h.DeleteComment).Methods("DELETE")
+
+	// Staff-only routes; each group requires a permission on top of a
+	// valid token
+	moderation := protected.PathPrefix("/moderation").Subrouter()
+	moderation.Use(controllers.RequirePermission(models.PermissionModerateStories))
+	moderation.HandleFunc("/stories", h.ListModerationStories).Methods("GET")
+	moderation.HandleFunc("/stories/{id:[0-9]+}/approve", h.ApproveStory).Methods("POST")
+	moderation.HandleFunc("/stories/{id:[0-9]+}/reject", h.RejectStory).Methods("POST")
+
+	// Registered before the rest of /admin, which needs another permission
+	tags := protected.PathPrefix("/admin/tags").Subrouter()
+	tags.Use(controllers.RequirePermission(models.PermissionManageTags))
+	tags.HandleFunc("", h.ListAllTags).Methods("GET")
+	tags.HandleFunc("/{id:[0-9]+}", h.RenameTag).Methods("PUT")
+	tags.HandleFunc("/{id:[0-9]+}/merge", h.MergeTag).Methods("POST")
+
+	comments := protected.PathPrefix("/admin/comments").Subrouter()
+	comments.Use(controllers.RequirePermission(models.PermissionModerateComments))
+	comments.HandleFunc("/{id:[0-9]+}/hide", h.HideComment).Methods("POST")
+	comments.HandleFunc("/{id:[0-9]+}/unhide", h.UnhideComment).Methods("POST")
+
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(controllers.RequirePermission(models.PermissionManageUsers))
+	admin.HandleFunc("/roles", h.ListRoles).Methods("GET")
+	admin.HandleFunc("/users", h.ListUsers).Methods("GET")
+	admin.HandleFunc("/users/{id:[0-9]+}", h.GetUser).Methods("GET")
+	admin.HandleFunc("/users/{id:[0-9]+}", h.DeleteUser).Methods("DELETE")
+	admin.HandleFunc("/users/{id:[0-9]+}/disable", h.DisableUser).Methods("POST")
+	admin.HandleFunc("/users/{id:[0-9]+}/enable", h.EnableUser).Methods("POST")
+	admin.HandleFunc("/users/{id:[0-9]+}/logout", h.LogoutUser).Methods("POST")
+	admin.HandleFunc("/users/{id:[0-9]+}/role", h.UpdateUserRole).Methods("PUT")
+
+	// Serve uploaded files from the configured storage backend
+	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", h.Uploads()))
 
 	// Set up CORS
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000"}, // Adjust to your frontend's origin
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
 		AllowCredentials: true,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type"},
-		Debug:            true, // Set to true only during development
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		Debug:            cfg.CORS.Debug,
 	})
 
-	// Wrap the router with the CORS handler
-	return c.Handler(r)
+	// Health and build-info probes bypass CORS; everything else goes
+	// through the CORS-wrapped router
+	root := mux.NewRouter()
+	root.HandleFunc("/healthz", health.Liveness).Methods("GET", "HEAD")
+	root.HandleFunc("/readyz", health.Readiness).Methods("GET", "HEAD")
+	root.HandleFunc("/version", health.Version).Methods("GET")
+	root.PathPrefix("/").Handler(c.Handler(r))
+
+	return root
 }
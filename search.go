@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"blog_project.com/repositories"
+)
+
+// runSearch implements the "search reindex" subcommand.
+//
+//	reindex  rebuild the search index from the stored stories, needed
+//	         on MySQL after the text extraction changes
+func runSearch(db *sql.DB, args []string) error {
+	if len(args) != 1 || args[0] != "reindex" {
+		return errors.New("usage: search reindex")
+	}
+
+	count, err := repositories.New(db).Search.Reindex(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("Indexed %d stories", count)
+	return nil
+}
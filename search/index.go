@@ -0,0 +1,96 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// BM25 parameters: k1 limits how much repeating a term raises the score
+// and b how much long documents are penalised.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Index is an in-process inverted index of documents identified by int
+// IDs, ranked with Okapi BM25.
+//
+// An Index is not safe for concurrent writes; build it fully before
+// searching it from several goroutines.
+type Index struct {
+	postings    map[string]map[int]int // term -> document ID -> occurrences
+	lengths     map[int]int            // document ID -> number of terms
+	totalLength int
+}
+
+// Match is a document found by Index.Search.
+type Match struct {
+	ID    int
+	Score float64
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{postings: map[string]map[int]int{}, lengths: map[int]int{}}
+}
+
+// Add indexes text as the document id. Each ID must only be added once.
+func (ix *Index) Add(id int, text string) {
+	terms := Tokenize(text)
+	for _, term := range terms {
+		if ix.postings[term] == nil {
+			ix.postings[term] = map[int]int{}
+		}
+		ix.postings[term][id]++
+	}
+	ix.lengths[id] = len(terms)
+	ix.totalLength += len(terms)
+}
+
+// Len returns the number of documents in the index.
+func (ix *Index) Len() int {
+	return len(ix.lengths)
+}
+
+// Search returns the documents containing any term of query, best first
+// and, for equal scores, newest (highest ID) first. At most limit
+// documents are returned.
+func (ix *Index) Search(query string, limit int) []Match {
+	if len(ix.lengths) == 0 {
+		return nil
+	}
+	n := float64(len(ix.lengths))
+	averageLength := float64(ix.totalLength) / n
+
+	scores := map[int]float64{}
+	seen := map[string]bool{}
+	for _, term := range Tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		postings := ix.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, occurrences := range postings {
+			tf := float64(occurrences)
+			norm := 1 - bm25B + bm25B*float64(ix.lengths[id])/averageLength
+			scores[id] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+
+	matches := make([]Match, 0, len(scores))
+	for id, score := range scores {
+		matches = append(matches, Match{ID: id, Score: score})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID > matches[j].ID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
@@ -0,0 +1,152 @@
+// Package search holds the text handling shared by the searchers: how
+// story documents are turned into plain text, how text is split into
+// terms, an in-process inverted index and highlighted snippets.
+//
+// Terms are lower-cased runs of letters and digits of at least three
+// characters, so "E-mail" yields "mail" and punctuation never matches.
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minTermLength is the length, in characters, below which words are not
+// indexed or searched for. It matches the default innodb_ft_min_token_size
+// of MySQL, so a query finds the same stories on every backend.
+const minTermLength = 3
+
+// DocumentText returns the text of a story document: every string value
+// it holds, object members in key order, one per line. Links are left
+// out, as their words are noise in search results.
+func DocumentText(content interface{}) string {
+	var lines []string
+	collectText(content, &lines)
+	return strings.Join(lines, "\n")
+}
+
+func collectText(value interface{}, lines *[]string) {
+	switch v := value.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" && !isLink(v) {
+			*lines = append(*lines, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectText(item, lines)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			collectText(v[key], lines)
+		}
+	}
+}
+
+func isLink(s string) bool {
+	return !strings.ContainsAny(s, " \t\n") && (strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://"))
+}
+
+// Tokenize returns the terms of text, in order and with repeats.
+func Tokenize(text string) []string {
+	var terms []string
+	for _, w := range words(text) {
+		if term := strings.ToLower(text[w.start:w.end]); utf8.RuneCountInString(term) >= minTermLength {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// span is the byte range of a word in a string.
+type span struct{ start, end int }
+
+// words returns the maximal runs of letters and digits in text.
+func words(text string) []span {
+	var spans []span
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(text)})
+	}
+	return spans
+}
+
+// snippetLength is roughly how many characters of text a snippet shows.
+const snippetLength = 160
+
+// Snippet returns an excerpt of text around the first term of query it
+// contains, with every term of query wrapped in <mark> and </mark>. The
+// rest of the excerpt is HTML-escaped, so the snippet can be inserted
+// into a page as is. Whitespace is collapsed and an ellipsis marks text
+// left out at either end.
+func Snippet(text, query string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	terms := map[string]bool{}
+	for _, term := range Tokenize(query) {
+		terms[term] = true
+	}
+	spans := words(text)
+	matches := func(w span) bool { return terms[strings.ToLower(text[w.start:w.end])] }
+
+	// Start a little before the first match, on a word boundary
+	first := 0
+	for i, w := range spans {
+		if matches(w) {
+			first = i
+			break
+		}
+	}
+	start := 0
+	for i := first; i >= 0 && first < len(spans); i-- {
+		if utf8.RuneCountInString(text[spans[i].start:spans[first].start]) > snippetLength/3 {
+			break
+		}
+		start = spans[i].start
+	}
+	if first == 0 {
+		start = 0
+	}
+	end := len(text)
+	for _, w := range spans {
+		if w.start > start && utf8.RuneCountInString(text[start:w.end]) > snippetLength {
+			end = w.start
+			break
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	last := start
+	for _, w := range spans {
+		if w.start < start || w.end > end || !matches(w) {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:w.start]))
+		b.WriteString("<mark>" + html.EscapeString(text[w.start:w.end]) + "</mark>")
+		last = w.end
+	}
+	b.WriteString(html.EscapeString(strings.TrimRight(text[last:end], " ")))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
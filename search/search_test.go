@@ -0,0 +1,23 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"E-mail the FBI", []string{"mail", "the", "fbi"}},
+		{"an ox", nil},
+		{"Ünïcode, café! 2024", []string{"ünïcode", "café", "2024"}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FileServer serves the objects of b over HTTP, using the request path
+// (without its leading slash) as the key. Mount it behind
+// http.StripPrefix.
+func FileServer(b Blob) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		object, info, err := b.Get(r.Context(), key)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidKey) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer object.Close()
+
+		if info.ContentType != "" {
+			w.Header().Set("Content-Type", info.ContentType)
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		// Seekable objects get range and conditional request support.
+		if seeker, ok := object.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, key, info.ModTime, seeker)
+			return
+		}
+
+		if info.Size > 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+		}
+		if !info.ModTime.IsZero() {
+			w.Header().Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		io.Copy(w, object)
+	})
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Memory keeps objects in process memory. It is meant for tests.
+type Memory struct {
+	publicURL string
+
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+}
+
+// NewMemory returns an empty Memory backend whose URLs are built by
+// appending the key to publicURL.
+func NewMemory(publicURL string) *Memory {
+	return &Memory{publicURL: publicURL, objects: map[string]memoryObject{}}
+}
+
+// Put implements Blob.
+func (m *Memory) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = memoryObject{
+		data: data,
+		info: ObjectInfo{ContentType: contentType, Size: int64(len(data)), ModTime: time.Now()},
+	}
+	return nil
+}
+
+// Get implements Blob.
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	object, ok := m.objects[key]
+	if !ok {
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	return readSeekNopCloser{bytes.NewReader(object.data)}, object.info, nil
+}
+
+// Delete implements Blob.
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// URL implements Blob.
+func (m *Memory) URL(key string) string {
+	return joinURL(m.publicURL, key)
+}
+
+// readSeekNopCloser lets FileServer seek in in-memory objects.
+type readSeekNopCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekNopCloser) Close() error { return nil }
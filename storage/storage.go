@@ -0,0 +1,103 @@
+// Package storage stores uploaded files behind the Blob interface.
+//
+// Handlers never touch the filesystem directly: they put objects under
+// a key, persist the key, and hand out the URL the backend reports for
+// it. The local backend keeps files on disk, the S3 backend talks to any
+// S3-compatible object store and the memory backend is meant for tests.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"blog_project.com/config"
+)
+
+var (
+	// ErrNotFound is returned when no object exists under a key.
+	ErrNotFound = errors.New("storage: object not found")
+
+	// ErrInvalidKey is returned for keys that are empty, absolute or try
+	// to escape the storage root.
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// Blob stores opaque objects under slash-separated keys.
+type Blob interface {
+	// Put stores the content of r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get opens the object stored under key. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// Delete removes the object under key. Deleting a missing object is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the URL clients use to download the object.
+	URL(key string) string
+}
+
+// Checker is implemented by backends that can report whether they are
+// currently able to store objects.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Check reports whether b can store objects. Backends that do not
+// implement Checker are assumed to be healthy.
+func Check(ctx context.Context, b Blob) error {
+	if checker, ok := b.(Checker); ok {
+		return checker.Check(ctx)
+	}
+	return nil
+}
+
+// New returns the backend selected by cfg.
+func New(cfg config.StorageConfig) (Blob, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocal(cfg.Local.Dir, cfg.PublicURL)
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			PathStyle:       cfg.S3.PathStyle,
+			PublicURL:       cfg.PublicURL,
+		})
+	case "memory":
+		return NewMemory(cfg.PublicURL), nil
+	default:
+		return nil, fmt.Errorf("storage: unsupported driver %q", cfg.Driver)
+	}
+}
+
+// cleanKey validates a key and returns it in canonical form.
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || strings.ContainsRune(key, 0) {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return path.Clean(key), nil
+}
+
+// joinURL appends key to a public base URL.
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrTooLarge is returned when an upload, or the form carrying it,
+	// exceeds the configured size limit.
+	ErrTooLarge = errors.New("storage: upload too large")
+
+	// ErrUnsupportedType is returned when the content of an upload is not
+	// one of the allowed types.
+	ErrUnsupportedType = errors.New("storage: unsupported upload type")
+
+	// ErrMissingFile is returned when the form has no file in the field.
+	ErrMissingFile = errors.New("storage: no file uploaded")
+
+	// ErrMalformedForm is returned when the request is not a valid
+	// multipart form.
+	ErrMalformedForm = errors.New("storage: malformed multipart form")
+)
+
+// ImageTypes are the content types accepted for image uploads, mapped to
+// the extension given to their keys. They are the formats the standard
+// library can decode, since every image is re-encoded before it is
+// stored.
+var ImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// formOverhead is the room left in a request body for the non-file form
+// fields and multipart boundaries on top of the file size limit.
+const formOverhead = 1 << 20
+
+// Uploader is the single path by which files sent by clients reach a
+// Blob.
+//
+// The name the client gave the file is never used: objects are stored
+// under a random key with an extension derived from the detected type.
+// The type is sniffed from the content itself, not taken from the
+// request headers, and must be one of the allowed types.
+type Uploader struct {
+	blob     Blob
+	maxBytes int64
+	types    map[string]string
+}
+
+// NewUploader returns an Uploader storing files of at most maxBytes whose
+// sniffed content type is a key of types.
+func NewUploader(blob Blob, maxBytes int64, types map[string]string) *Uploader {
+	return &Uploader{blob: blob, maxBytes: maxBytes, types: types}
+}
+
+// Blob returns the backend uploads are stored in.
+func (u *Uploader) Blob() Blob {
+	return u.blob
+}
+
+// MaxBytes returns the size limit for a single file.
+func (u *Uploader) MaxBytes() int64 {
+	return u.maxBytes
+}
+
+// ParseForm limits the size of the request body and parses it as a
+// multipart form. It must be called before reading any form value.
+func (u *Uploader) ParseForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, u.maxBytes+formOverhead)
+	if err := r.ParseMultipartForm(u.maxBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return ErrTooLarge
+		}
+		return ErrMalformedForm
+	}
+	return nil
+}
+
+// Receive reads the file uploaded in field, enforcing the size limit,
+// and returns its content together with the sniffed content type.
+func (u *Uploader) Receive(w http.ResponseWriter, r *http.Request, field string) ([]byte, string, error) {
+	file, _, err := r.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, "", ErrMissingFile
+	}
+	if err != nil {
+		return nil, "", ErrMalformedForm
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, file, u.maxBytes))
+	if err != nil {
+		return nil, "", uploadError(err)
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := u.types[contentType]; !ok {
+		return nil, "", ErrUnsupportedType
+	}
+	return data, contentType, nil
+}
+
+// Save stores the file uploaded in field under a new key starting with
+// prefix and returns the key.
+func (u *Uploader) Save(ctx context.Context, w http.ResponseWriter, r *http.Request, field, prefix string) (string, error) {
+	data, contentType, err := u.Receive(w, r, field)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := NewKey(prefix, u.types[contentType])
+	if err != nil {
+		return "", err
+	}
+	if err := u.blob.Put(ctx, key, bytes.NewReader(data), contentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// uploadError reports a read that hit the size limit as ErrTooLarge.
+func uploadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return ErrTooLarge
+	}
+	return err
+}
+
+// NewKey returns prefix followed by 128 random bits in hex and ext.
+func NewKey(prefix, ext string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf) + ext, nil
+}
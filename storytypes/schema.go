@@ -0,0 +1,325 @@
+package storytypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"blog_project.com/models"
+)
+
+// Schema is the subset of JSON Schema story types are written in.
+//
+// Only the keywords below are understood. Loading a schema that uses any
+// other keyword fails, so a schema never silently accepts documents its
+// author meant to refuse.
+type Schema struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Type is one of object, array, string, number, integer or boolean.
+	// An empty Type accepts any value.
+	Type string        `json:"type,omitempty"`
+	Enum []interface{} `json:"enum,omitempty"`
+
+	// Objects
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	// AdditionalProperties, when false, rejects members not listed in
+	// Properties.
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+
+	// Arrays
+	Items       *Schema `json:"items,omitempty"`
+	MinItems    *int    `json:"minItems,omitempty"`
+	MaxItems    *int    `json:"maxItems,omitempty"`
+	UniqueItems bool    `json:"uniqueItems,omitempty"`
+
+	// Strings. Lengths count characters, not bytes. Format is one of
+	// date, date-time, email or uri.
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	Format    string `json:"format,omitempty"`
+
+	// Numbers
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes lists the values Schema.Type accepts.
+var schemaTypes = map[string]bool{
+	"": true, "object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true,
+}
+
+// formats checks the string formats Schema.Format accepts.
+var formats = map[string]func(string) bool{
+	"date": func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	},
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		address, err := mail.ParseAddress(s)
+		return err == nil && address.Address == s
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	},
+}
+
+// ParseSchema decodes a schema and checks that it only uses the
+// supported keywords.
+func ParseSchema(data []byte) (*Schema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var schema Schema
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, err
+	}
+	if err := schema.compile(""); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// compile checks the keyword values of s and its subschemas and compiles
+// their patterns. path locates s in error messages.
+func (s *Schema) compile(path string) error {
+	if !schemaTypes[s.Type] {
+		return fmt.Errorf("%s: unsupported type %q", pathOrRoot(path), s.Type)
+	}
+	if s.Format != "" && formats[s.Format] == nil {
+		return fmt.Errorf("%s: unsupported format %q", pathOrRoot(path), s.Format)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pathOrRoot(path), err)
+		}
+		s.pattern = pattern
+	}
+	for _, name := range s.Required {
+		if s.Properties[name] == nil {
+			return fmt.Errorf("%s: required property %q is not defined", pathOrRoot(path), name)
+		}
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s: property %q has no schema", pathOrRoot(path), name)
+		}
+		if err := property.compile(joinField(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// Validate checks value, the output of json.Unmarshal into an
+// interface{}, against s and returns every problem found, in a stable
+// order. It returns nil when value matches.
+func (s *Schema) Validate(value interface{}) []models.FieldError {
+	var errs []models.FieldError
+	s.validate(value, "", &errs)
+	return errs
+}
+
+func (s *Schema) validate(value interface{}, path string, errs *[]models.FieldError) {
+	report := func(format string, args ...interface{}) {
+		*errs = append(*errs, models.FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !s.matchesType(value) {
+		report("must be %s", typeNames[s.Type])
+		return
+	}
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		report("must be one of %s", enumList(s.Enum))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(v, path, errs)
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			report("must have at least %d %s", *s.MinItems, plural(*s.MinItems, "item"))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			report("must have at most %d %s", *s.MaxItems, plural(*s.MaxItems, "item"))
+		}
+		if s.UniqueItems && hasDuplicates(v) {
+			report("must not contain duplicate items")
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, path+"["+strconv.Itoa(i)+"]", errs)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			if *s.MinLength == 1 {
+				report("must not be empty")
+			} else {
+				report("must be at least %d characters long", *s.MinLength)
+			}
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			report("must match the pattern %s", s.Pattern)
+		}
+		if s.Format != "" && !formats[s.Format](v) {
+			report("must be a valid %s", formatNames[s.Format])
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			report("must be at least %s", formatNumber(*s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			report("must be at most %s", formatNumber(*s.Maximum))
+		}
+	}
+}
+
+func (s *Schema) validateObject(object map[string]interface{}, path string, errs *[]models.FieldError) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			*errs = append(*errs, models.FieldError{Field: joinField(path, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		switch {
+		case ok:
+			property.validate(object[name], joinField(path, name), errs)
+		case s.AdditionalProperties != nil && !*s.AdditionalProperties:
+			*errs = append(*errs, models.FieldError{Field: joinField(path, name), Message: "is not allowed"})
+		}
+	}
+}
+
+// typeNames completes the sentence "must be ..." for every Schema.Type.
+var typeNames = map[string]string{
+	"object":  "an object",
+	"array":   "an array",
+	"string":  "a string",
+	"number":  "a number",
+	"integer": "a whole number",
+	"boolean": "true or false",
+}
+
+// formatNames completes the sentence "must be a valid ..." for every
+// Schema.Format.
+var formatNames = map[string]string{
+	"date":      "date (YYYY-MM-DD)",
+	"date-time": "RFC 3339 date and time",
+	"email":     "email address",
+	"uri":       "http or https URL",
+}
+
+func (s *Schema) matchesType(value interface{}) bool {
+	switch s.Type {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return true
+}
+
+func (s *Schema) inEnum(value interface{}) bool {
+	for _, allowed := range s.Enum {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDuplicates(items []interface{}) bool {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if reflect.DeepEqual(items[i], items[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func enumList(values []interface{}) string {
+	list := make([]string, len(values))
+	for i, value := range values {
+		data, _ := json.Marshal(value)
+		list[i] = string(data)
+	}
+	return strings.Join(list, ", ")
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// joinField returns the path of the member name of the object at path.
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
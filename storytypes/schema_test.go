@@ -0,0 +1,173 @@
+package storytypes
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"blog_project.com/models"
+)
+
+func TestParseSchemaRejectsUnsupportedSchemas(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"unknown keyword", `{"type": "string", "oneOf": []}`, `unknown field "oneOf"`},
+		{"unknown type", `{"type": "null"}`, `(root): unsupported type "null"`},
+		{"unknown format", `{"properties": {"site": {"type": "string", "format": "hostname"}}}`, `site: unsupported format "hostname"`},
+		{"invalid pattern", `{"items": {"pattern": "("}}`, `[]: error parsing regexp`},
+		{"undefined required property", `{"type": "object", "required": ["title"]}`, `(root): required property "title" is not defined`},
+		{"property without schema", `{"properties": {"title": null}}`, `(root): property "title" has no schema`},
+		{"nested", `{"properties": {"meta": {"properties": {"tags": {"items": {"type": "list"}}}}}}`, `meta.tags[]: unsupported type "list"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema([]byte(tt.schema))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseSchema(%s): err = %v, want one containing %q", tt.schema, err, tt.want)
+			}
+		})
+	}
+}
+
+const testSchema = `{
+	"type": "object",
+	"required": ["title", "body"],
+	"additionalProperties": false,
+	"properties": {
+		"title": {"type": "string", "minLength": 1, "maxLength": 5},
+		"body": {"type": "string", "minLength": 3},
+		"slug": {"type": "string", "pattern": "^[a-z-]+$"},
+		"kind": {"enum": ["news", "essay", 3]},
+		"rating": {"type": "integer", "minimum": 1, "maximum": 5},
+		"price": {"type": "number", "minimum": 0.5},
+		"draft": {"type": "boolean"},
+		"published": {"type": "string", "format": "date"},
+		"updated": {"type": "string", "format": "date-time"},
+		"contact": {"type": "string", "format": "email"},
+		"link": {"type": "string", "format": "uri"},
+		"tags": {
+			"type": "array",
+			"minItems": 1,
+			"maxItems": 2,
+			"uniqueItems": true,
+			"items": {"type": "string", "minLength": 2}
+		},
+		"meta": {"type": "object", "properties": {"source": {"type": "string"}}}
+	}
+}`
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		want     []models.FieldError
+	}{
+		{
+			name:     "valid",
+			document: `{"title": "Hëllo", "body": "abc", "slug": "a-b", "kind": 3, "rating": 5, "price": 0.5, "draft": false, "published": "2024-02-29", "updated": "2024-02-29T10:00:00Z", "contact": "a@b.c", "link": "https://example.com/x", "tags": ["go", "db"], "meta": {"source": "x", "extra": 1}}`,
+		},
+		{
+			name:     "not an object",
+			document: `["title"]`,
+			want:     []models.FieldError{{Field: "", Message: "must be an object"}},
+		},
+		{
+			name:     "missing and extra members",
+			document: `{"subtitle": "x", "author": "y"}`,
+			want: []models.FieldError{
+				{Field: "title", Message: "is required"},
+				{Field: "body", Message: "is required"},
+				{Field: "author", Message: "is not allowed"},
+				{Field: "subtitle", Message: "is not allowed"},
+			},
+		},
+		{
+			name:     "string lengths count characters",
+			document: `{"title": "", "body": "ab"}`,
+			want: []models.FieldError{
+				{Field: "body", Message: "must be at least 3 characters long"},
+				{Field: "title", Message: "must not be empty"},
+			},
+		},
+		{
+			name:     "string too long",
+			document: `{"title": "ëëëëëë", "body": "abc"}`,
+			want:     []models.FieldError{{Field: "title", Message: "must be at most 5 characters long"}},
+		},
+		{
+			name:     "wrong types",
+			document: `{"title": 1, "body": "abc", "draft": "no", "rating": 2.5, "price": "1"}`,
+			want: []models.FieldError{
+				{Field: "draft", Message: "must be true or false"},
+				{Field: "price", Message: "must be a number"},
+				{Field: "rating", Message: "must be a whole number"},
+				{Field: "title", Message: "must be a string"},
+			},
+		},
+		{
+			name:     "enum and pattern",
+			document: `{"title": "a", "body": "abc", "kind": "review", "slug": "A b"}`,
+			want: []models.FieldError{
+				{Field: "kind", Message: `must be one of "news", "essay", 3`},
+				{Field: "slug", Message: "must match the pattern ^[a-z-]+$"},
+			},
+		},
+		{
+			name:     "number bounds",
+			document: `{"title": "a", "body": "abc", "rating": 0, "price": 0.25}`,
+			want: []models.FieldError{
+				{Field: "price", Message: "must be at least 0.5"},
+				{Field: "rating", Message: "must be at least 1"},
+			},
+		},
+		{
+			name:     "formats",
+			document: `{"title": "a", "body": "abc", "published": "2023-02-29", "updated": "2024-01-01 10:00", "contact": "A <a@b.c>", "link": "ftp://example.com"}`,
+			want: []models.FieldError{
+				{Field: "contact", Message: "must be a valid email address"},
+				{Field: "link", Message: "must be a valid http or https URL"},
+				{Field: "published", Message: "must be a valid date (YYYY-MM-DD)"},
+				{Field: "updated", Message: "must be a valid RFC 3339 date and time"},
+			},
+		},
+		{
+			name:     "array bounds and items",
+			document: `{"title": "a", "body": "abc", "tags": ["go", "go", "x"]}`,
+			want: []models.FieldError{
+				{Field: "tags", Message: "must have at most 2 items"},
+				{Field: "tags", Message: "must not contain duplicate items"},
+				{Field: "tags[2]", Message: "must be at least 2 characters long"},
+			},
+		},
+		{
+			name:     "empty array",
+			document: `{"title": "a", "body": "abc", "tags": []}`,
+			want:     []models.FieldError{{Field: "tags", Message: "must have at least 1 item"}},
+		},
+		{
+			name:     "nested object",
+			document: `{"title": "a", "body": "abc", "meta": {"source": false}}`,
+			want:     []models.FieldError{{Field: "meta.source", Message: "must be a string"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document interface{}
+			if err := json.Unmarshal([]byte(tt.document), &document); err != nil {
+				t.Fatal(err)
+			}
+			if got := schema.Validate(document); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%s) =\n%v\nwant\n%v", tt.document, got, tt.want)
+			}
+		})
+	}
+}
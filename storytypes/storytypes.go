@@ -0,0 +1,141 @@
+// Package storytypes defines the kinds of stories users can write, such
+// as blog posts or testimonials, and checks story documents against them.
+//
+// Every type is a JSON Schema file embedded into the binary and named
+// <type>.json. The legacy type accepts any object; it is the type of the
+// stories written before types existed, which keep loading and can still
+// be edited, but new stories cannot use it.
+package storytypes
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"blog_project.com/models"
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Legacy is the type of stories written before story types existed.
+const Legacy = "legacy"
+
+// ErrUnknownType is returned for a type name that is not registered.
+var ErrUnknownType = errors.New("storytypes: unknown story type")
+
+// Type is a registered story type.
+type Type struct {
+	Name   string
+	Schema *Schema
+	// Raw is the schema file, returned as is to clients that want to
+	// validate stories before sending them.
+	Raw json.RawMessage
+}
+
+// Model describes t for API responses.
+func (t *Type) Model() models.StoryTypeModel {
+	return models.StoryTypeModel{
+		Name:        t.Name,
+		Title:       t.Schema.Title,
+		Description: t.Schema.Description,
+		Schema:      t.Raw,
+	}
+}
+
+// Registry holds the known story types.
+type Registry struct {
+	types map[string]*Type
+	names []string
+}
+
+// Default is the registry of the embedded story types.
+var Default = mustLoad()
+
+func mustLoad() *Registry {
+	sub, err := fs.Sub(files, "schemas")
+	if err != nil {
+		panic(err)
+	}
+	registry, err := Load(sub)
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// Load reads a registry from the *.json schema files at the root of
+// fsys. It must contain the legacy type.
+func Load(fsys fs.FS) (*Registry, error) {
+	paths, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &Registry{types: map[string]*Type{}}
+	for _, p := range paths {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := ParseSchema(data)
+		if err != nil {
+			return nil, fmt.Errorf("storytypes: %s: %w", p, err)
+		}
+		if schema.Type != "object" {
+			return nil, fmt.Errorf("storytypes: %s: story schemas must describe an object", p)
+		}
+		name := strings.TrimSuffix(path.Base(p), ".json")
+		registry.types[name] = &Type{Name: name, Schema: schema, Raw: json.RawMessage(data)}
+		registry.names = append(registry.names, name)
+	}
+	if registry.types[Legacy] == nil {
+		return nil, fmt.Errorf("storytypes: the %s type is missing", Legacy)
+	}
+	sort.Strings(registry.names)
+	return registry, nil
+}
+
+// Lookup returns the type called name.
+func (r *Registry) Lookup(name string) (*Type, bool) {
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// Types returns the types new stories can be written in, sorted by name.
+// The legacy type is left out.
+func (r *Registry) Types() []*Type {
+	types := make([]*Type, 0, len(r.names))
+	for _, name := range r.names {
+		if name != Legacy {
+			types = append(types, r.types[name])
+		}
+	}
+	return types
+}
+
+// Creatable reports whether new stories can be written in the type
+// called name.
+func (r *Registry) Creatable(name string) bool {
+	_, ok := r.types[name]
+	return ok && name != Legacy
+}
+
+// Validate checks document against the schema of the type called name.
+// It returns ErrUnknownType when there is no such type, and otherwise
+// the problems found, or nil when the document matches.
+func (r *Registry) Validate(name string, document map[string]interface{}) ([]models.FieldError, error) {
+	t, ok := r.types[name]
+	if !ok {
+		return nil, ErrUnknownType
+	}
+	if document == nil {
+		document = map[string]interface{}{}
+	}
+	return t.Schema.Validate(document), nil
+}
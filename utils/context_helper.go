@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"context"
+
+	"blog_project.com/models"
+)
+
+// principalKey is the unexported context key under which the
+// authenticated principal is stored.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the given principal.
+func WithPrincipal(ctx context.Context, principal models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in ctx by the
+// authentication middleware, if any.
+func PrincipalFromContext(ctx context.Context) (models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(models.Principal)
+	return principal, ok
+}
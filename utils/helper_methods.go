@@ -2,33 +2,52 @@ package utils
 
 import (
 	"fmt"
-	"os"
+	"math"
 	"time"
 
+	"blog_project.com/models"
 	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // GenerateToken generates a new JWT token for a user.
 //
-// It takes the user ID and email as parameters and creates a token
-// that includes these claims along with an expiration time of 72 hours.
+// It takes the user ID, email, roles and the ID of the login session the
+// token belongs to, and creates a short-lived access token that includes
+// these claims and expires after AccessTokenTTL. Every token gets a unique
+// "jti" claim so it can be revoked individually before it expires. The
+// "iat" claim has microseconds, so tokens issued right after all tokens
+// of a user were revoked are told apart from the revoked ones. Longer
+// sessions are kept alive with refresh tokens.
 //
 // Returns the signed token as a string and an error if any occurs
 // during the signing process.
-func GenerateToken(userID int, email string) (string, error) {
+func GenerateToken(userID int, email string, roles []string, sessionID string) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", errMissingSecret
+	}
+
+	tokenID, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(), // Token expires in 72 hours
+		"roles":   roles,
+		"sid":     sessionID,
+		"jti":     tokenID,
+		"iat":     float64(now.UnixMicro()) / 1e6,
+		"exp":     now.Add(AccessTokenTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 	return token.SignedString(jwtSecret) // Return the signed token
 }
 
 // CheckPasswordHash checks if the provided password matches the hashed password.
-// 
+//
 // It takes the plain text password and the hashed password as parameters.
 // Returns an error if the passwords do not match or nil if they do match.
 func CheckPasswordHash(password, hash string) error {
@@ -36,34 +55,79 @@ func CheckPasswordHash(password, hash string) error {
 }
 
 // HashPassword hashes a plain text password using bcrypt.
-// 
+//
 // It takes the plain text password as a parameter and returns the hashed
 // password as a string along with any error that may occur during the hashing process.
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost) // Generate the hash
-	return string(bytes), err // Return the hashed password and any error
+	return string(bytes), err                                                       // Return the hashed password and any error
 }
 
-
-// ParseToken parses the JWT token and returns the claims and any error encountered.
+// ParseToken parses the JWT token and returns the user ID it was issued for.
 func ParseToken(tokenString string) (int, error) {
-	
+	principal, err := ParseClaims(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	return principal.UserID, nil
+}
+
+// ParseClaims parses and validates the JWT token and returns the
+// identity carried by its claims.
+//
+// Tokens whose ID, or whose user, has been revoked through the
+// registered TokenRevocationList are rejected even if they have not
+// expired yet, and so are the tokens of disabled users, with
+// ErrAccountDisabled.
+func ParseClaims(tokenString string) (models.Principal, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// Verify that the token method is what we expect
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		if len(jwtSecret) == 0 {
+			return nil, errMissingSecret
+		}
 		// Return the secret key
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		return jwtSecret, nil
 	})
-	
-
 	if err != nil {
-		return 0, err
+		return models.Principal{}, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return models.Principal{}, fmt.Errorf("invalid token")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return models.Principal{}, fmt.Errorf("invalid token: missing user_id claim")
+	}
+
+	principal := models.Principal{UserID: int(userID)}
+	principal.Email, _ = claims["email"].(string)
+	principal.TokenID, _ = claims["jti"].(string)
+	principal.SessionID, _ = claims["sid"].(string)
+	if iat, ok := claims["iat"].(float64); ok {
+		seconds, fraction := math.Modf(iat)
+		principal.IssuedAt = time.Unix(int64(seconds), int64(fraction*1e9)).Round(time.Microsecond)
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		principal.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	if roles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				principal.Roles = append(principal.Roles, name)
+			}
+		}
+	}
+
+	if revocations != nil && revocations.IsDisabled(principal.UserID) {
+		return models.Principal{}, ErrAccountDisabled
 	}
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := int(claims["user_id"].(float64)) // convert float64 to int
-		return userID, nil
+	if revocations != nil && revocations.IsRevoked(principal) {
+		return models.Principal{}, fmt.Errorf("token has been revoked")
 	}
-	return 0, fmt.Errorf("invalid token")
+	return principal, nil
 }
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"blog_project.com/models"
+)
+
+// DiffJSON returns the changes that turn the JSON value from into to.
+//
+// Both values are expected to be the output of json.Unmarshal into an
+// interface{}. Objects are compared member by member, in key order, and
+// arrays element by element, so a change deep inside a document is
+// reported at its own path rather than as a replaced parent. Any other
+// pair of differing values, including values of different kinds, is a
+// single replace.
+func DiffJSON(from, to interface{}) []models.JSONChange {
+	changes := []models.JSONChange{}
+	diffJSON(from, to, "", &changes)
+	return changes
+}
+
+func diffJSON(from, to interface{}, path string, changes *[]models.JSONChange) {
+	switch fromValue := from.(type) {
+	case map[string]interface{}:
+		if toValue, ok := to.(map[string]interface{}); ok {
+			diffObjects(fromValue, toValue, path, changes)
+			return
+		}
+	case []interface{}:
+		if toValue, ok := to.([]interface{}); ok {
+			diffArrays(fromValue, toValue, path, changes)
+			return
+		}
+	}
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, models.JSONChange{Op: "replace", Path: path, From: from, To: to})
+	}
+}
+
+func diffObjects(from, to map[string]interface{}, path string, changes *[]models.JSONChange) {
+	keys := make([]string, 0, len(from)+len(to))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		memberPath := path + "/" + escapePointer(key)
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		switch {
+		case !inFrom:
+			*changes = append(*changes, models.JSONChange{Op: "add", Path: memberPath, To: toValue})
+		case !inTo:
+			*changes = append(*changes, models.JSONChange{Op: "remove", Path: memberPath, From: fromValue})
+		default:
+			diffJSON(fromValue, toValue, memberPath, changes)
+		}
+	}
+}
+
+func diffArrays(from, to []interface{}, path string, changes *[]models.JSONChange) {
+	for i := 0; i < len(from) || i < len(to); i++ {
+		elementPath := path + "/" + strconv.Itoa(i)
+		switch {
+		case i >= len(from):
+			*changes = append(*changes, models.JSONChange{Op: "add", Path: elementPath, To: to[i]})
+		case i >= len(to):
+			*changes = append(*changes, models.JSONChange{Op: "remove", Path: elementPath, From: from[i]})
+		default:
+			diffJSON(from[i], to[i], elementPath, changes)
+		}
+	}
+}
+
+// escapePointer escapes a member name for use in a JSON Pointer.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
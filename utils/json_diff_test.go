@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"blog_project.com/models"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     []models.JSONChange
+	}{
+		{
+			name: "equal documents",
+			from: `{"title": "a", "tags": ["x", {"y": 1}]}`,
+			to:   `{"tags": ["x", {"y": 1}], "title": "a"}`,
+			want: []models.JSONChange{},
+		},
+		{
+			name: "members in key order",
+			from: `{"title": "a", "body": "b", "draft": true}`,
+			to:   `{"title": "c", "body": "b", "author": "d"}`,
+			want: []models.JSONChange{
+				{Op: "add", Path: "/author", To: "d"},
+				{Op: "remove", Path: "/draft", From: true},
+				{Op: "replace", Path: "/title", From: "a", To: "c"},
+			},
+		},
+		{
+			name: "nested change at its own path",
+			from: `{"meta": {"source": {"name": "x", "url": "u"}}}`,
+			to:   `{"meta": {"source": {"name": "y", "url": "u"}}}`,
+			want: []models.JSONChange{
+				{Op: "replace", Path: "/meta/source/name", From: "x", To: "y"},
+			},
+		},
+		{
+			name: "arrays element by element",
+			from: `{"tags": ["a", "b", "c"]}`,
+			to:   `{"tags": ["a", "x"]}`,
+			want: []models.JSONChange{
+				{Op: "replace", Path: "/tags/1", From: "b", To: "x"},
+				{Op: "remove", Path: "/tags/2", From: "c"},
+			},
+		},
+		{
+			name: "grown array",
+			from: `[1]`,
+			to:   `[1, 2, [3]]`,
+			want: []models.JSONChange{
+				{Op: "add", Path: "/1", To: 2.0},
+				{Op: "add", Path: "/2", To: []interface{}{3.0}},
+			},
+		},
+		{
+			name: "different kinds are replaced",
+			from: `{"body": {"text": "a"}, "tags": ["a"], "count": 1}`,
+			to:   `{"body": "a", "tags": {"0": "a"}, "count": "1"}`,
+			want: []models.JSONChange{
+				{Op: "replace", Path: "/body", From: map[string]interface{}{"text": "a"}, To: "a"},
+				{Op: "replace", Path: "/count", From: 1.0, To: "1"},
+				{Op: "replace", Path: "/tags", From: []interface{}{"a"}, To: map[string]interface{}{"0": "a"}},
+			},
+		},
+		{
+			name: "null is a value",
+			from: `{"summary": null}`,
+			to:   `{"summary": "s"}`,
+			want: []models.JSONChange{
+				{Op: "replace", Path: "/summary", From: nil, To: "s"},
+			},
+		},
+		{
+			name: "whole document replaced",
+			from: `"a"`,
+			to:   `2`,
+			want: []models.JSONChange{
+				{Op: "replace", Path: "", From: "a", To: 2.0},
+			},
+		},
+		{
+			name: "member names are escaped",
+			from: `{"a/b": 1, "c~d": 1}`,
+			to:   `{"a/b": 2}`,
+			want: []models.JSONChange{
+				{Op: "replace", Path: "/a~1b", From: 1.0, To: 2.0},
+				{Op: "remove", Path: "/c~0d", From: 1.0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var from, to interface{}
+			if err := json.Unmarshal([]byte(tt.from), &from); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.to), &to); err != nil {
+				t.Fatal(err)
+			}
+			if got := DiffJSON(from, to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffJSON(%s, %s) =\n%#v\nwant\n%#v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONChangeKeepsNull(t *testing.T) {
+	var from, to interface{}
+	json.Unmarshal([]byte(`{"summary": null, "title": "a"}`), &from)
+	json.Unmarshal([]byte(`{"summary": "s", "title": null}`), &to)
+
+	got, err := json.Marshal(DiffJSON(from, to))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"replace","path":"/summary","from":null,"to":"s"},{"op":"replace","path":"/title","from":"a","to":null}]`
+	if string(got) != want {
+		t.Errorf("encoded changes = %s, want %s", got, want)
+	}
+}
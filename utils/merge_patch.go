@@ -0,0 +1,29 @@
+package utils
+
+// MergePatch applies a JSON merge patch (RFC 7396) to target and returns
+// the result.
+//
+// Both values are expected to be the output of json.Unmarshal into an
+// interface{}. When patch is an object its members are merged into
+// target recursively, with null members removing the matching key;
+// any other patch value replaces target entirely.
+func MergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = MergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
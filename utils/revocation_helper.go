@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"errors"
+
+	"blog_project.com/models"
+)
+
+// ErrAccountDisabled is returned by ParseClaims for tokens of accounts
+// that an admin has disabled.
+var ErrAccountDisabled = errors.New("account has been disabled")
+
+// TokenRevocationList decides whether an otherwise valid access token
+// must be refused because it was revoked before its expiry or because
+// its user has been disabled.
+type TokenRevocationList interface {
+	IsRevoked(principal models.Principal) bool
+	IsDisabled(userID int) bool
+}
+
+// revocations is consulted by ParseClaims for every token it validates.
+var revocations TokenRevocationList
+
+// SetRevocationList registers the revocation list used by ParseClaims.
+//
+// It should be called once during application startup, before the
+// server starts accepting requests.
+func SetRevocationList(list TokenRevocationList) {
+	revocations = list
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	// AccessTokenTTL is how long a signed JWT access token stays valid.
+	AccessTokenTTL = 15 * time.Minute
+
+	// RefreshTokenTTL is how long an unused refresh token can be
+	// exchanged for a new access token.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+
+	// jwtSecret is the HMAC key used to sign and verify access tokens.
+	jwtSecret []byte
+)
+
+// errMissingSecret is returned when a token is signed or verified before
+// ConfigureTokens provided a signing key.
+var errMissingSecret = errors.New("JWT secret is not configured")
+
+// ConfigureTokens sets the signing key and token lifetimes.
+//
+// It should be called once during application startup with the values
+// from the loaded configuration.
+func ConfigureTokens(secret string, accessTTL, refreshTTL time.Duration) {
+	jwtSecret = []byte(secret)
+	AccessTokenTTL = accessTTL
+	RefreshTokenTTL = refreshTTL
+}
+
+// RevocationCutoff returns the time before which access tokens must have
+// been issued to be revoked when every token of a user is revoked now.
+// It has the microsecond precision of the "iat" claim, so every token
+// issued so far, even within the current second, is before it.
+func RevocationCutoff() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// GenerateOpaqueToken returns a random, URL-safe token string.
+//
+// The token carries no information by itself; it is only meaningful
+// through the hash stored next to it in the database.
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex encoded SHA-256 digest of an opaque token.
+//
+// Only this digest is persisted so a leaked table cannot be replayed.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}